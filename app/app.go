@@ -1,10 +1,14 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"photo-app/helpers"
+	"photo-app/jobs"
+	"photo-app/mailer"
 	"photo-app/routes"
+	"photo-app/storage"
 	"reflect"
 	"strings"
 
@@ -18,15 +22,21 @@ import (
 
 type app struct {
 	port   uint
+	conf   helpers.Config
 	db     *gorm.DB
+	store  storage.Storage
+	mail   mailer.Mailer
 	r      *gin.Engine
 	logger *slog.Logger
 }
 
-func New(conf helpers.App, db *gorm.DB, logger *slog.Logger) *app {
+func New(conf helpers.Config, db *gorm.DB, store storage.Storage, mail mailer.Mailer, logger *slog.Logger) *app {
 	return &app{
-		port:   conf.Port,
+		port:   conf.App.Port,
+		conf:   conf,
 		db:     db,
+		store:  store,
+		mail:   mail,
 		r:      gin.Default(),
 		logger: logger,
 	}
@@ -46,19 +56,44 @@ func (app *app) Start() error {
 
 	app.r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	wellKnown := app.r.Group("/.well-known")
+	{
+		routes.NewWellKnownRoutes(wellKnown)
+	}
+
 	v1 := app.r.Group("/api/v1")
 
 	users := v1.Group("/users")
 	{
-		routes.NewUserRoutes(users, app.db, app.logger)
+		routes.NewUserRoutes(users, app.db, app.store, app.mail, app.conf.Auth, app.conf.Mail, app.logger)
 	}
 
 	photosApi := v1.Group("/photos")
+	photosFiles := app.r.Group("/files")
 	photosStatic := app.r.Group("/photos")
 	{
-		routes.NewPhotoRoutes(photosApi, photosStatic, app.db, app.logger)
+		routes.NewPhotoRoutes(photosApi, photosFiles, photosStatic, app.db, app.store, app.conf.Photo, app.logger)
 	}
 
+	tags := v1.Group("/tags")
+	{
+		routes.NewTagRoutes(tags, app.db, app.conf.Photo, app.logger)
+	}
+
+	albums := v1.Group("/albums")
+	{
+		routes.NewAlbumRoutes(albums, app.db, app.conf.Photo, app.logger)
+	}
+
+	shares := v1.Group("/shares")
+	{
+		routes.NewShareRoutes(shares, app.db, app.logger)
+	}
+
+	go jobs.NewTrashPurger(app.db, app.store, app.conf.Photo, app.logger).Run(context.Background())
+	go jobs.NewFileCleaner(app.db, app.store, app.conf.Storage, app.logger).Run(context.Background())
+	go jobs.NewMetadataStripper(app.db, app.store, app.conf.Photo, app.logger).Run(context.Background())
+
 	app.logger.Info("Server starting", "port", app.port)
 	if err := app.r.Run(fmt.Sprintf(":%d", app.port)); err != nil {
 		return err
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"photo-app/dtos"
+	"photo-app/helpers"
+	"photo-app/models"
+	"photo-app/repositories"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type AlbumController interface {
+	GetByID(context.Context, string) (dtos.AlbumResponse, error)
+	GetByOwner(context.Context, string) (helpers.AlbumsResponse, error)
+	GetByUserID(context.Context, string) (helpers.AlbumsResponse, error)
+	Create(context.Context, dtos.CreateAlbumRequest) (dtos.CreateAlbumResponse, error)
+	Update(context.Context, dtos.UpdateAlbumRequest, string) error
+	Delete(context.Context, string) error
+	AddPhoto(context.Context, dtos.AddAlbumPhotoRequest, string) error
+	RemovePhoto(context.Context, string, string) error
+	ReorderPhotos(context.Context, dtos.ReorderAlbumPhotosRequest, string) error
+}
+
+type albumController struct {
+	repo      repositories.AlbumRepository
+	photoRepo repositories.PhotoRepository
+	userRepo  repositories.UserRepository
+	shares    *shareController
+	signer    helpers.URLSigner
+	logger    *slog.Logger
+}
+
+func NewAlbumController(repo repositories.AlbumRepository, photoRepo repositories.PhotoRepository, userRepo repositories.UserRepository, shareRepo repositories.ShareRepository, signer helpers.URLSigner, logger *slog.Logger) AlbumController {
+	return &albumController{repo, photoRepo, userRepo, &shareController{repo: shareRepo, logger: logger}, signer, logger}
+}
+
+func (c *albumController) GetByID(ctx context.Context, id string) (dtos.AlbumResponse, error) {
+	var res dtos.AlbumResponse
+
+	album, shared, err := c.findVisible(ctx, id)
+	if err != nil {
+		c.logger.Error("Albums [GET BY ID]", "error", err.Error())
+		var errResponse helpers.ResponseError
+		if errors.As(err, &errResponse) {
+			return res, err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("album with specified ID can't be found"), http.StatusNotFound)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	// a shared album shows every one of its photos, they can be viewed with the same link.
+	findPhotos := c.repo.FindPhotos
+	if shared {
+		findPhotos = c.repo.FindSharedPhotos
+	}
+
+	photos, err := findPhotos(ctx, album.ID)
+	if err != nil {
+		c.logger.Error("Albums [GET BY ID]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res = c.albumResponse(album)
+	res.Owner = &dtos.UserResponse{
+		Usename: album.User.Username,
+	}
+	res.Photos = make([]dtos.AlbumPhotoResponse, len(photos))
+	for i, photo := range photos {
+		res.Photos[i] = dtos.AlbumPhotoResponse{
+			Position:      photo.Position,
+			PhotoResponse: photoResponse(photo.Photo, c.signer),
+		}
+	}
+
+	return res, nil
+}
+
+func (c *albumController) GetByOwner(ctx context.Context, username string) (helpers.AlbumsResponse, error) {
+	var res helpers.AlbumsResponse
+
+	user, err := c.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		c.logger.Error("Albums [GET BY OWNER]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("user with specified username can't be found"), http.StatusNotFound)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return c.GetByUserID(ctx, user.ID)
+}
+
+func (c *albumController) GetByUserID(ctx context.Context, userID string) (helpers.AlbumsResponse, error) {
+	var res helpers.AlbumsResponse
+
+	albums, err := c.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		c.logger.Error("Albums [GET BY USER ID]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	data := make([]dtos.AlbumResponse, len(albums))
+	for i, album := range albums {
+		data[i] = c.albumResponse(album)
+	}
+
+	res.Albums = data
+	return res, nil
+}
+
+func (c *albumController) Create(ctx context.Context, data dtos.CreateAlbumRequest) (dtos.CreateAlbumResponse, error) {
+	var res dtos.CreateAlbumResponse
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if data.CoverPhotoID != nil && *data.CoverPhotoID != "" {
+		err := c.checkPhotoOwner(ctx, *data.CoverPhotoID, userID, "Albums [CREATE]")
+		if err != nil {
+			return res, err
+		}
+	} else {
+		data.CoverPhotoID = nil
+	}
+
+	album := models.Album{
+		ID:           uuid.NewString(),
+		Title:        data.Title,
+		Description:  data.Description,
+		CoverPhotoID: data.CoverPhotoID,
+		UserID:       userID,
+		Visibility:   data.Visibility,
+	}
+	if album.Visibility == "" {
+		album.Visibility = models.VisibilityPublic
+	}
+
+	albumID, err := c.repo.Create(ctx, album)
+	if err != nil {
+		c.logger.Error("Albums [CREATE]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.ID = albumID
+	return res, nil
+}
+
+func (c *albumController) Update(ctx context.Context, data dtos.UpdateAlbumRequest, id string) error {
+	album, userID, err := c.findOwnAlbum(ctx, id, "Albums [UPDATE]")
+	if err != nil {
+		return err
+	}
+
+	toUpdate := make(map[string]any)
+	if data.Title != nil {
+		if *data.Title == "" {
+			return helpers.NewResponseError(errors.New("title can't be empty"), http.StatusBadRequest)
+		}
+		toUpdate["title"] = *data.Title
+	}
+	if data.Description != nil {
+		toUpdate["description"] = *data.Description
+	}
+	if data.Visibility != nil {
+		toUpdate["visibility"] = *data.Visibility
+	}
+	if data.CoverPhotoID != nil {
+		// an empty cover photo ID removes the cover.
+		toUpdate["cover_photo_id"] = nil
+		if *data.CoverPhotoID != "" {
+			err = c.checkPhotoOwner(ctx, *data.CoverPhotoID, userID, "Albums [UPDATE]")
+			if err != nil {
+				return err
+			}
+			toUpdate["cover_photo_id"] = *data.CoverPhotoID
+		}
+	}
+
+	err = c.repo.Update(ctx, album, toUpdate)
+	if err != nil {
+		c.logger.Error("Albums [UPDATE]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *albumController) Delete(ctx context.Context, id string) error {
+	album, _, err := c.findOwnAlbum(ctx, id, "Albums [DELETE]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.Delete(ctx, album)
+	if err != nil {
+		c.logger.Error("Albums [DELETE]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *albumController) AddPhoto(ctx context.Context, data dtos.AddAlbumPhotoRequest, id string) error {
+	album, userID, err := c.findOwnAlbum(ctx, id, "Albums [ADD PHOTO]")
+	if err != nil {
+		return err
+	}
+
+	err = c.checkPhotoOwner(ctx, data.PhotoID, userID, "Albums [ADD PHOTO]")
+	if err != nil {
+		return err
+	}
+
+	position := -1
+	if data.Position != nil {
+		position = *data.Position
+	}
+
+	err = c.repo.AddPhoto(ctx, album.ID, data.PhotoID, position)
+	if err != nil {
+		c.logger.Error("Albums [ADD PHOTO]", "error", err.Error())
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == "23505" {
+			return helpers.NewResponseError(errors.New("photo is already in this album"), http.StatusConflict)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *albumController) RemovePhoto(ctx context.Context, id, photoID string) error {
+	album, _, err := c.findOwnAlbum(ctx, id, "Albums [REMOVE PHOTO]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.RemovePhoto(ctx, album.ID, photoID)
+	if err != nil {
+		c.logger.Error("Albums [REMOVE PHOTO]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("photo with specified ID isn't in this album"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *albumController) ReorderPhotos(ctx context.Context, data dtos.ReorderAlbumPhotosRequest, id string) error {
+	album, _, err := c.findOwnAlbum(ctx, id, "Albums [REORDER PHOTOS]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.ReorderPhotos(ctx, album.ID, data.PhotoIDs)
+	if err != nil {
+		c.logger.Error("Albums [REORDER PHOTOS]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("photo_ids must contain every photo of the album exactly once"), http.StatusBadRequest)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// findVisible finds an album the current user can see, either on their own or through the share link of the request.
+// it reports whether the album was found through the link.
+func (c *albumController) findVisible(ctx context.Context, id string) (models.Album, bool, error) {
+	album, err := c.repo.FindByID(ctx, id)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return album, false, err
+	}
+
+	album, err = c.repo.FindSharedByID(ctx, id)
+	if err != nil {
+		return album, false, err
+	}
+
+	ok, err := c.shares.authorize(ctx, "", id, true)
+	if err != nil {
+		return album, false, err
+	}
+	if !ok {
+		return album, false, gorm.ErrRecordNotFound
+	}
+
+	return album, true, nil
+}
+
+// findOwnAlbum returns the album with the given ID along with the current user ID, failing unless the current user owns it.
+func (c *albumController) findOwnAlbum(ctx context.Context, id, action string) (models.Album, string, error) {
+	album, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return album, "", helpers.NewResponseError(errors.New("album with specified ID can't be found"), http.StatusNotFound)
+		}
+		return album, "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return album, "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if album.UserID != userID {
+		return album, "", helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	return album, userID, nil
+}
+
+// checkPhotoOwner makes sure the photo exists and belongs to the user, only own photos can be put in an album.
+func (c *albumController) checkPhotoOwner(ctx context.Context, photoID, userID, action string) error {
+	photo, err := c.photoRepo.FindByID(ctx, photoID)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if photo.UserID != userID {
+		return helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	return nil
+}
+
+func (c *albumController) albumResponse(album models.Album) dtos.AlbumResponse {
+	res := dtos.AlbumResponse{
+		ID:          album.ID,
+		Title:       album.Title,
+		Description: album.Description,
+		Visibility:  album.Visibility,
+	}
+
+	// the cover photo is only preloaded when the current user can see it.
+	if album.CoverPhoto != nil {
+		cover := photoResponse(*album.CoverPhoto, c.signer)
+		res.CoverPhoto = &cover
+	}
+
+	return res
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"photo-app/helpers"
+	"photo-app/mailer"
+	"strings"
+)
+
+// emailController sends the emails of other controllers.
+type emailController struct {
+	mailer mailer.Mailer
+	conf   helpers.Mail
+}
+
+// link returns the absolute URL of a path of the app with the given query, as written in emails.
+func (c *emailController) link(path string, q url.Values) string {
+	return strings.TrimSuffix(c.conf.LinkBaseURL, "/") + path + "?" + q.Encode()
+}
+
+func (c *emailController) send(ctx context.Context, msg mailer.Message) error {
+	err := c.mailer.Send(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("sending email to %s: %w", msg.To, err)
+	}
+
+	return nil
+}
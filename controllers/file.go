@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"photo-app/dtos"
+	"photo-app/models"
+	"photo-app/repositories"
+	"photo-app/storage"
+	"strings"
+	"time"
+)
+
+type FileController interface {
+	ProcessDeletions(context.Context) (int, error)
+	Reconcile(context.Context) (dtos.FileReport, error)
+}
+
+const (
+	// uploadGracePeriod is how long uploaded files are kept while their photo row isn't committed yet.
+	uploadGracePeriod = time.Hour
+	maxDeletionDelay  = time.Hour
+	deletionBatchSize = 100
+)
+
+type fileController struct {
+	repo   repositories.FileRepository
+	store  storage.Storage
+	logger *slog.Logger
+}
+
+func NewFileController(repo repositories.FileRepository, store storage.Storage, logger *slog.Logger) FileController {
+	return &fileController{repo, store, logger}
+}
+
+// ProcessDeletions removes the files of every due deletion. a failed deletion is retried later, with a growing delay.
+// it returns the number of processed deletions.
+func (c *fileController) ProcessDeletions(ctx context.Context) (int, error) {
+	var processed int
+
+	for {
+		deletions, err := c.repo.FindDue(ctx, time.Now(), deletionBatchSize)
+		if err != nil {
+			return processed, err
+		}
+
+		failed := c.delete(ctx, deletions)
+		processed += len(deletions) - failed
+
+		// failed deletions are postponed so they don't come back in the next batch, stop anyway if nothing
+		// could be processed.
+		if len(deletions) < deletionBatchSize || failed == len(deletions) {
+			return processed, nil
+		}
+	}
+}
+
+// Reconcile compares the stored files with the ones referenced by photos, their variants and versions.
+// files guarded by a pending upload or waiting to be deleted aren't reported.
+func (c *fileController) Reconcile(ctx context.Context) (dtos.FileReport, error) {
+	report := dtos.FileReport{
+		MissingFiles: make(map[string]string),
+	}
+
+	pending, err := c.repo.FindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	// the referenced files are loaded first, the stored ones are then walked without holding the whole storage in
+	// memory and checked off as they're found.
+	referenced := make(map[string]string)
+	var lastID string
+	for {
+		photos, err := c.repo.FindPhotoFiles(ctx, lastID, deletionBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, photo := range photos {
+			for _, filePath := range photoPaths(photo) {
+				referenced[filePath] = photo.ID
+			}
+			lastID = photo.ID
+		}
+
+		if len(photos) < deletionBatchSize {
+			break
+		}
+	}
+
+	err = c.store.Walk(ctx, "", func(obj storage.Object) error {
+		filePath := "/" + obj.Key
+		if _, ok := referenced[filePath]; ok {
+			delete(referenced, filePath)
+		} else if !isPending(filePath, pending) {
+			report.OrphanedFiles = append(report.OrphanedFiles, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for filePath, photoID := range referenced {
+		if !isPending(filePath, pending) {
+			report.MissingFiles[filePath] = photoID
+		}
+	}
+
+	return report, nil
+}
+
+// guard records a delayed deletion of every file under the given prefixes, it must be released along with
+// the commit of the upload. otherwise the files are deleted once the grace period is over.
+func (c *fileController) guard(ctx context.Context, prefixes ...string) ([]models.FileDeletion, error) {
+	notBefore := time.Now().Add(uploadGracePeriod)
+
+	guards := make([]models.FileDeletion, len(prefixes))
+	for i, prefix := range prefixes {
+		guards[i] = models.FileDeletion{
+			Path:      prefix,
+			Prefix:    true,
+			NotBefore: notBefore,
+		}
+	}
+
+	return c.repo.Create(ctx, guards)
+}
+
+// delete processes deletions right away and returns the number of failed ones, those are left for ProcessDeletions.
+func (c *fileController) delete(ctx context.Context, deletions []models.FileDeletion) int {
+	var failed int
+
+	for _, deletion := range deletions {
+		err := c.deleteFiles(ctx, deletion)
+		if err == nil {
+			err = c.repo.Delete(ctx, deletion)
+		}
+		if err == nil {
+			continue
+		}
+
+		failed++
+		c.logger.Error("Files [DELETE]", "path", deletion.Path, "error", err.Error())
+
+		delay := min(time.Minute<<deletion.Attempts, maxDeletionDelay)
+		err = c.repo.Postpone(ctx, deletion, err.Error(), time.Now().Add(delay))
+		if err != nil {
+			c.logger.Error("Files [DELETE]", "path", deletion.Path, "error", err.Error())
+		}
+	}
+
+	return failed
+}
+
+func (c *fileController) deleteFiles(ctx context.Context, deletion models.FileDeletion) error {
+	paths := []string{deletion.Path}
+	if deletion.Prefix {
+		objects, err := c.store.List(ctx, deletion.Path)
+		if err != nil {
+			return err
+		}
+
+		paths = paths[:0]
+		for _, obj := range objects {
+			paths = append(paths, obj.Key)
+		}
+	}
+
+	for _, filePath := range paths {
+		err := c.store.Delete(ctx, filePath)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileDeletions turns paths into deletions that are due right away, empty paths are skipped.
+func fileDeletions(paths ...string) []models.FileDeletion {
+	now := time.Now()
+
+	deletions := make([]models.FileDeletion, 0, len(paths))
+	for _, filePath := range paths {
+		if filePath == "" {
+			continue
+		}
+		deletions = append(deletions, models.FileDeletion{
+			Path:      filePath,
+			NotBefore: now,
+		})
+	}
+
+	return deletions
+}
+
+// photoPaths returns every file of a photo: the served file, original, variants and previous versions.
+func photoPaths(photo models.Photo) []string {
+	paths := make([]string, 0, 2+len(photo.Variants))
+	for _, filePath := range []string{photo.PhotoPath, photo.OriginalPath} {
+		if filePath != "" {
+			paths = append(paths, filePath)
+		}
+	}
+	for _, variant := range photo.Variants {
+		paths = append(paths, variant.PhotoPath)
+	}
+	for _, version := range photo.Versions {
+		for _, filePath := range version.Paths() {
+			if filePath != "" {
+				paths = append(paths, filePath)
+			}
+		}
+	}
+
+	return paths
+}
+
+func isPending(filePath string, pending []models.FileDeletion) bool {
+	for _, deletion := range pending {
+		if deletion.Path == filePath || (deletion.Prefix && strings.HasPrefix(filePath, deletion.Path)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,62 +1,106 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"path"
 	"photo-app/dtos"
 	"photo-app/helpers"
 	"photo-app/models"
 	"photo-app/repositories"
+	"photo-app/storage"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type PhotoController interface {
-	GetAll(context.Context) ([]dtos.PhotoResponse, error)
-	GetByOwner(context.Context, string) ([]dtos.PhotoResponse, error)
-	GetByUserID(context.Context, string) ([]dtos.PhotoResponse, error)
+	GetAll(context.Context, dtos.PhotoListQuery) (helpers.PhotosResponse, error)
+	GetByOwner(context.Context, string, dtos.PhotoListQuery) (helpers.PhotosResponse, error)
+	GetByUserID(context.Context, string, dtos.PhotoListQuery) (helpers.PhotosResponse, error)
+	GetByID(context.Context, string) (dtos.PhotoDetailResponse, error)
 	Create(context.Context, dtos.CreatePhotoRequest) (dtos.CreatePhotoResponse, error)
-	Update(context.Context, dtos.UpdatePhotoRequest, string) error
+	Update(context.Context, dtos.UpdatePhotoRequest, string, string) error
 	Delete(context.Context, string) error
 	IsAllowedToView(context.Context, string) (bool, error)
+	GetPhotoFile(context.Context, string, string, int, dtos.PhotoFileQuery) (io.ReadCloser, dtos.PhotoFileResponse, error)
+	ResolveLegacyPath(context.Context, string) (string, error)
+	GetMetadata(context.Context, string) (dtos.PhotoMetadataResponse, error)
+	GetOriginal(context.Context, string) (io.ReadCloser, storage.Object, error)
+	Search(context.Context, dtos.PhotoSearchQuery) (helpers.SearchResponse, error)
+	ReplaceFile(context.Context, dtos.ReplacePhotoRequest, string) (dtos.ReplacePhotoResponse, error)
+	GetVersions(context.Context, string) (helpers.PhotoVersionsResponse, error)
+	RestoreVersion(context.Context, string, int) error
+	DeleteVersion(context.Context, string, int) error
+	PurgeVersions(context.Context, string, dtos.PurgePhotoVersionsQuery) error
+	GetTrash(context.Context) (helpers.TrashResponse, error)
+	Restore(context.Context, string) error
+	EmptyTrash(context.Context) error
+	PurgeTrash(context.Context) (int, error)
+	StripLegacyFiles(context.Context) (int, error)
 }
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	purgeBatchSize   = 100
+)
+
 type photoController struct {
 	repo     repositories.PhotoRepository
 	userRepo repositories.UserRepository
+	files    *fileController
+	shares   *shareController
+	signer   helpers.URLSigner
+	store    storage.Storage
+	conf     helpers.Photo
 	logger   *slog.Logger
 }
 
-func NewPhotoController(repo repositories.PhotoRepository, userRepo repositories.UserRepository, logger *slog.Logger) PhotoController {
-	return &photoController{repo, userRepo, logger}
+func NewPhotoController(repo repositories.PhotoRepository, userRepo repositories.UserRepository, fileRepo repositories.FileRepository, shareRepo repositories.ShareRepository, store storage.Storage, conf helpers.Photo, logger *slog.Logger) PhotoController {
+	files := &fileController{repo: fileRepo, store: store, logger: logger}
+	shares := &shareController{repo: shareRepo, logger: logger}
+	return &photoController{repo, userRepo, files, shares, helpers.NewURLSigner(conf), store, conf, logger}
 }
 
-func (c *photoController) GetAll(ctx context.Context) ([]dtos.PhotoResponse, error) {
-	photos, err := c.repo.FindAll(ctx)
+func (c *photoController) GetAll(ctx context.Context, q dtos.PhotoListQuery) (helpers.PhotosResponse, error) {
+	var res helpers.PhotosResponse
+
+	query, cursor, err := pageQuery(q)
+	if err != nil {
+		return res, err
+	}
+
+	photos, err := c.repo.FindAll(ctx, query)
 	if err != nil {
 		c.logger.Error("Photos [GET ALL]", "error", err.Error())
-		return nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
+	photos, res.NextCursor, res.PrevCursor = paginate(photos, query, cursor, q.Cursor != "")
+
 	data := make([]dtos.PhotoResponse, len(photos))
 	for i, photo := range photos {
-		data[i] = dtos.PhotoResponse{
-			ID:        photo.ID,
-			Title:     photo.Title,
-			Caption:   photo.Caption,
-			PhotoPath: filepath.ToSlash(filepath.Join("/photos", photo.PhotoPath)),
-			Owner: &dtos.UserResponse{
-				Usename: photo.User.Username,
-			},
+		data[i] = photoResponse(photo, c.signer)
+		data[i].Owner = &dtos.UserResponse{
+			Usename: photo.User.Username,
 		}
 	}
 
-	return data, nil
+	res.Photos = data
+	res.ETag, res.Public = pageETag(photos, res.NextCursor, res.PrevCursor)
+	return res, nil
 }
 
 func (c *photoController) Create(ctx context.Context, data dtos.CreatePhotoRequest) (dtos.CreatePhotoResponse, error) {
@@ -67,26 +111,48 @@ func (c *photoController) Create(ctx context.Context, data dtos.CreatePhotoReque
 		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
-	photoID := uuid.NewString()
-
-	filePath, err := helpers.SaveFile(ctx, data.Photo, photoID)
+	user, err := c.userRepo.FindByID(ctx, id)
 	if err != nil {
 		c.logger.Error("Photos [CREATE]", "error", err.Error())
 		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
-	photo := models.Photo{
-		Title:     data.Title,
-		Caption:   data.Caption,
-		PhotoPath: filePath,
-		IsPrivate: data.IsPrivate,
-		UserID:    id,
+	if user.EmailVerifiedAt == nil {
+		return res, helpers.NewResponseError(helpers.ErrEmailNotVerified, http.StatusForbidden)
+	}
+
+	stripMode, keepOriginal := user.StripMetadata, user.KeepOriginal
+	if data.StripMetadata != "" {
+		stripMode = data.StripMetadata
+	}
+	if data.KeepOriginal != nil {
+		keepOriginal = *data.KeepOriginal
+	}
+
+	tags := helpers.NormalizeTags(data.Tags)
+	if len(tags) > helpers.MaxPhotoTags {
+		return res, helpers.NewResponseError(fmt.Errorf("a photo can't have more than %d tags", helpers.MaxPhotoTags), http.StatusBadRequest)
 	}
 
+	photoID := uuid.NewString()
+	photo, guards, err := c.storeFile(ctx, "Photos [CREATE]", data.Photo, id, photoID, photoID, stripMode, keepOriginal)
+	if err != nil {
+		return res, err
+	}
+
+	photo.Title = data.Title
+	photo.Caption = data.Caption
+	photo.Visibility = data.Visibility
+	if photo.Visibility == "" {
+		photo.Visibility = models.VisibilityPublic
+	}
+	photo.UserID = id
+	photo.Tags = tagModels(tags)
 	photo.ID = photoID
-	_, err = c.repo.Create(ctx, photo)
+	_, err = c.repo.Create(ctx, photo, guards)
 	if err != nil {
 		c.logger.Error("Photos [CREATE]", "error", err.Error())
+		c.files.delete(ctx, guards)
 		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
@@ -94,7 +160,9 @@ func (c *photoController) Create(ctx context.Context, data dtos.CreatePhotoReque
 	return res, nil
 }
 
-func (c *photoController) Update(ctx context.Context, data dtos.UpdatePhotoRequest, id string) error {
+// Update updates data of a photo, ifMatch is the If-Match header of the request and must be the current ETag of
+// the photo when it's set.
+func (c *photoController) Update(ctx context.Context, data dtos.UpdatePhotoRequest, id, ifMatch string) error {
 	photo, err := c.repo.FindByID(ctx, id)
 	if err != nil {
 		c.logger.Error("Photos [UPDATE]", "error", err.Error())
@@ -113,6 +181,10 @@ func (c *photoController) Update(ctx context.Context, data dtos.UpdatePhotoReque
 		return helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
 	}
 
+	if !helpers.IfMatch(ifMatch, photoETag(photo, true)) {
+		return helpers.NewResponseError(helpers.ErrPreconditionFailed, http.StatusPreconditionFailed)
+	}
+
 	toUpdate := make(map[string]any)
 	if data.Caption != nil {
 		toUpdate["caption"] = *data.Caption
@@ -120,13 +192,26 @@ func (c *photoController) Update(ctx context.Context, data dtos.UpdatePhotoReque
 	if data.Title != nil {
 		toUpdate["title"] = data.Title
 	}
-	if data.IsPrivate != nil {
-		toUpdate["is_private"] = data.IsPrivate
+	if data.Visibility != nil {
+		toUpdate["visibility"] = *data.Visibility
+	}
+
+	var tags []models.Tag
+	if data.Tags != nil {
+		names := helpers.NormalizeTags(*data.Tags)
+		if len(names) > helpers.MaxPhotoTags {
+			return helpers.NewResponseError(fmt.Errorf("a photo can't have more than %d tags", helpers.MaxPhotoTags), http.StatusBadRequest)
+		}
+		tags = tagModels(names)
 	}
 
-	err = c.repo.Update(ctx, photo, toUpdate)
+	err = c.repo.Update(ctx, photo, toUpdate, tags)
 	if err != nil {
 		c.logger.Error("Photos [UPDATE]", "error", err.Error())
+		// the photo was found above, so it has been changed by another request since.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(helpers.ErrPreconditionFailed, http.StatusPreconditionFailed)
+		}
 		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
@@ -152,12 +237,7 @@ func (c *photoController) Delete(ctx context.Context, id string) error {
 		return helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
 	}
 
-	err = helpers.RemoveFile(photo.PhotoPath)
-	if err != nil {
-		c.logger.Error("Photos [DELETE]", "error", err.Error())
-		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
-	}
-
+	// the photo is only moved to the trash, its files are removed once it's purged.
 	err = c.repo.Delete(ctx, photo)
 	if err != nil {
 		c.logger.Error("Photos [DELETE]", "error", err.Error())
@@ -167,53 +247,1151 @@ func (c *photoController) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (c *photoController) GetByOwner(ctx context.Context, username string) ([]dtos.PhotoResponse, error) {
+func (c *photoController) GetByOwner(ctx context.Context, username string, q dtos.PhotoListQuery) (helpers.PhotosResponse, error) {
+	var res helpers.PhotosResponse
+
 	user, err := c.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		c.logger.Error("Photos [GET BY OWNER]", "error", err.Error())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, helpers.NewResponseError(errors.New("user with specified username can't be found"), http.StatusNotFound)
+			return res, helpers.NewResponseError(errors.New("user with specified username can't be found"), http.StatusNotFound)
 		}
-		return nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
-	data, err := c.GetByUserID(ctx, user.ID)
+	res, err = c.GetByUserID(ctx, user.ID, q)
 	if err != nil {
-		return nil, err
+		return res, err
 	}
 
-	return data, nil
+	return res, nil
 }
 
-func (c *photoController) GetByUserID(ctx context.Context, userID string) ([]dtos.PhotoResponse, error) {
-	photos, err := c.repo.FindByUserID(ctx, userID)
+func (c *photoController) GetByUserID(ctx context.Context, userID string, q dtos.PhotoListQuery) (helpers.PhotosResponse, error) {
+	var res helpers.PhotosResponse
+
+	query, cursor, err := pageQuery(q)
+	if err != nil {
+		return res, err
+	}
+
+	photos, err := c.repo.FindByUserID(ctx, userID, query)
 	if err != nil {
 		c.logger.Error("Photos [GET BY USER ID]", "error", err.Error())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, helpers.NewResponseError(errors.New("user with specified user_id can't be found"), http.StatusNotFound)
+			return res, helpers.NewResponseError(errors.New("user with specified user_id can't be found"), http.StatusNotFound)
 		}
-		return nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
+	photos, res.NextCursor, res.PrevCursor = paginate(photos, query, cursor, q.Cursor != "")
+
 	data := make([]dtos.PhotoResponse, len(photos))
 	for i, photo := range photos {
-		data[i] = dtos.PhotoResponse{
-			ID:        photo.ID,
-			Title:     photo.Title,
-			Caption:   photo.Caption,
-			PhotoPath: filepath.ToSlash(filepath.Join("/photos", photo.PhotoPath)),
+		data[i] = photoResponse(photo, c.signer)
+	}
+
+	res.Photos = data
+	res.ETag, res.Public = pageETag(photos, res.NextCursor, res.PrevCursor)
+	return res, nil
+}
+
+func (c *photoController) GetByID(ctx context.Context, id string) (dtos.PhotoDetailResponse, error) {
+	var res dtos.PhotoDetailResponse
+
+	photo, err := c.findVisible(ctx, id, true)
+	if err != nil {
+		c.logger.Error("Photos [GET BY ID]", "error", err.Error())
+		var errResponse helpers.ResponseError
+		if errors.As(err, &errResponse) {
+			return res, err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
 		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res = dtos.PhotoDetailResponse{
+		PhotoResponse: photoResponse(photo, c.signer),
+		Width:         photo.Width,
+		Height:        photo.Height,
+		FileSize:      photo.Size,
+		CreatedAt:     photo.CreatedAt,
+		UpdatedAt:     photo.UpdatedAt,
+	}
+	res.Owner = &dtos.UserResponse{
+		Usename: photo.User.Username,
 	}
+	if photo.UserID == ctx.Value("id") {
+		res.Visibility = photo.Visibility
+	}
+	res.ETag = photoETag(photo, photo.UserID == ctx.Value("id"))
+	res.Public = photo.Visibility == models.VisibilityPublic
 
-	return data, nil
+	return res, nil
 }
 
 func (c *photoController) IsAllowedToView(ctx context.Context, photoID string) (bool, error) {
-	fmt.Println("USER ID = ", ctx.Value("id"))
-	photo, err := c.repo.FindByID(ctx, photoID)
+	// files are requested along with the photo, so they don't count another view of a share link.
+	_, err := c.findVisible(ctx, photoID, false)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
 		return false, err
 	}
 
-	return (!photo.IsPrivate || photo.UserID == ctx.Value("id").(string)), nil
+	return true, nil
+}
+
+// GetPhotoFile returns the served file of a photo, or one of its variants when variant isn't empty. a non-zero
+// version selects a previous file of the photo, those are only served to the owner or through a signed URL.
+func (c *photoController) GetPhotoFile(ctx context.Context, id, variant string, version int, query dtos.PhotoFileQuery) (io.ReadCloser, dtos.PhotoFileResponse, error) {
+	var res dtos.PhotoFileResponse
+
+	photo, err := c.repo.FindFilesByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, res, helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+		}
+		c.logger.Error("Photos [GET PHOTO FILE]", "error", err.Error())
+		return nil, res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	signed, _ := ctx.Value("signed_url").(bool)
+	if version != 0 && version != photo.FileVersion && !signed && photo.UserID != ctx.Value("id") {
+		return nil, res, helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+	}
+
+	filePath, checksum, ok := photoFile(photo, variant, version)
+	if !ok {
+		return nil, res, helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+	}
+
+	file, obj, err := c.GetFile(ctx, filePath)
+	if err != nil {
+		return nil, res, err
+	}
+
+	// files stored before checksums were recorded get theirs on their first serve, so every file has a strong ETag.
+	if checksum == "" {
+		fileVersion := version
+		if fileVersion == photo.FileVersion {
+			fileVersion = 0
+		}
+		file, checksum, err = c.checksumFile(ctx, photo.ID, fileVersion, variant, filePath, file)
+		if err != nil {
+			return nil, res, err
+		}
+	}
+
+	res = dtos.PhotoFileResponse{
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.ModTime,
+		Public:      photo.Visibility == models.VisibilityPublic,
+		// a version number in the path or in the v query of the current file pins the URL to a single file.
+		Immutable: version != 0 || (query.Version != 0 && query.Version == photo.FileVersion),
+		ETag:      `"` + checksum + `"`,
+	}
+
+	return file, res, nil
+}
+
+// checksumFile reads a file stored without its checksum and records it, it returns the checksum along with a reader
+// over the read file.
+func (c *photoController) checksumFile(ctx context.Context, photoID string, version int, variant, filePath string, file io.ReadCloser) (io.ReadCloser, string, error) {
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.logger.Error("Photos [GET PHOTO FILE]", "error", err.Error())
+		return nil, "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	checksum := helpers.Checksum(data)
+	err = c.repo.SetChecksum(ctx, photoID, version, variant, filePath, checksum)
+	if err != nil {
+		// the file is served anyway, its checksum is recorded on a later request.
+		c.logger.Error("Photos [GET PHOTO FILE]", "error", err.Error())
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), checksum, nil
+}
+
+// ResolveLegacyPath returns the URL of a file that used to be served at /photos/<userID>/<photoID>..., the URL is
+// signed when the request was made with a valid signed URL and the photo isn't public.
+func (c *photoController) ResolveLegacyPath(ctx context.Context, filePath string) (string, error) {
+	// legacy paths are /<userID>/<photoID><suffix> or /<userID>/<photoID>/<name><suffix>, IDs being UUIDs.
+	parts := strings.SplitN(strings.TrimPrefix(filePath, "/"), "/", 3)
+	if len(parts) < 2 || len(parts[1]) < 36 {
+		return "", helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+	}
+
+	photo, err := c.repo.FindFilesByID(ctx, parts[1][:36])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+		}
+		c.logger.Error("Photos [RESOLVE LEGACY PATH]", "error", err.Error())
+		return "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	suffix, ok := legacyFileSuffix(photo, filePath)
+	if !ok {
+		return "", helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+	}
+
+	if signed, _ := ctx.Value("signed_url").(bool); signed || photo.Visibility == models.VisibilityPublic {
+		return fileURL(photo, suffix, c.signer), nil
+	}
+
+	return "/files/" + photo.ID + suffix, nil
+}
+
+func (c *photoController) GetFile(ctx context.Context, filePath string) (io.ReadCloser, storage.Object, error) {
+	file, obj, err := c.store.Get(ctx, filePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return nil, obj, helpers.NewResponseError(errors.New("file can't be found"), http.StatusNotFound)
+		}
+		c.logger.Error("Photos [GET FILE]", "error", err.Error())
+		return nil, obj, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return file, obj, nil
+}
+
+func (c *photoController) GetMetadata(ctx context.Context, id string) (dtos.PhotoMetadataResponse, error) {
+	var res dtos.PhotoMetadataResponse
+
+	photo, err := c.findVisible(ctx, id, true)
+	if err != nil {
+		c.logger.Error("Photos [GET METADATA]", "error", err.Error())
+		var errResponse helpers.ResponseError
+		if errors.As(err, &errResponse) {
+			return res, err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	metadata, err := c.repo.FindMetadataByPhotoID(ctx, photo.ID)
+	if err != nil {
+		c.logger.Error("Photos [GET METADATA]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("metadata of this photo can't be found"), http.StatusNotFound)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	// the location was stripped from the served file, so only the owner may see it. photos uploaded before metadata
+	// stripping existed have no mode, their location is hidden as well since it was never meant to be published.
+	showLocation := photo.StripMetadata == helpers.StripNone || photo.UserID == ctx.Value("id")
+	if !showLocation {
+		metadata.Latitude, metadata.Longitude, metadata.Altitude = nil, nil, nil
+	}
+
+	res = dtos.PhotoMetadataResponse{
+		CameraMake:   metadata.CameraMake,
+		CameraModel:  metadata.CameraModel,
+		LensModel:    metadata.LensModel,
+		Software:     metadata.Software,
+		FocalLength:  metadata.FocalLength,
+		Aperture:     metadata.Aperture,
+		ExposureTime: metadata.ExposureTime,
+		ISO:          metadata.ISO,
+		Orientation:  metadata.Orientation,
+		TakenAt:      metadata.TakenAt,
+		Latitude:     metadata.Latitude,
+		Longitude:    metadata.Longitude,
+		Altitude:     metadata.Altitude,
+		// the metadata is only changed along with the file, which increases the version of the photo.
+		ETag: helpers.ETag(photo.ID, photo.Version, showLocation),
+	}
+
+	return res, nil
+}
+
+func (c *photoController) GetOriginal(ctx context.Context, id string) (io.ReadCloser, storage.Object, error) {
+	var obj storage.Object
+
+	photo, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error("Photos [GET ORIGINAL]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, obj, helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
+		}
+		return nil, obj, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return nil, obj, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if photo.UserID != userID {
+		return nil, obj, helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	originalPath := photo.OriginalPath
+	// nothing was stripped, the served file is the original.
+	if originalPath == "" && (photo.StripMetadata == "" || photo.StripMetadata == helpers.StripNone) {
+		originalPath = photo.PhotoPath
+	}
+	if originalPath == "" {
+		return nil, obj, helpers.NewResponseError(errors.New("original file of this photo isn't kept"), http.StatusNotFound)
+	}
+
+	return c.GetFile(ctx, originalPath)
+}
+
+func (c *photoController) Search(ctx context.Context, q dtos.PhotoSearchQuery) (helpers.SearchResponse, error) {
+	var res helpers.SearchResponse
+
+	text := strings.TrimSpace(q.Query)
+	if text == "" {
+		return res, helpers.NewResponseError(errors.New("q can't be empty"), http.StatusBadRequest)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	limit = min(limit, maxPageLimit)
+
+	page := max(q.Page, 1)
+
+	// every word of the query is matched against the tags, and so is the whole query ("new york" -> new-york).
+	query := repositories.SearchQuery{
+		Text:   text,
+		Tags:   helpers.NormalizeTags(append(strings.Fields(text), text)),
+		Limit:  limit + 1,
+		Offset: (page - 1) * limit,
+	}
+
+	photos, err := c.repo.Search(ctx, query)
+	if err != nil {
+		c.logger.Error("Photos [SEARCH]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if len(photos) > limit {
+		photos = photos[:limit]
+		res.NextPage = page + 1
+	}
+
+	data := make([]dtos.PhotoResponse, len(photos))
+	for i, photo := range photos {
+		data[i] = photoResponse(photo, c.signer)
+		data[i].Owner = &dtos.UserResponse{
+			Usename: photo.User.Username,
+		}
+	}
+
+	res.Photos = data
+	res.ETag, res.Public = pageETag(photos, res.NextPage)
+	return res, nil
+}
+
+func (c *photoController) ReplaceFile(ctx context.Context, data dtos.ReplacePhotoRequest, id string) (dtos.ReplacePhotoResponse, error) {
+	var res dtos.ReplacePhotoResponse
+
+	photo, err := c.findOwnPhoto(ctx, id, "Photos [REPLACE FILE]")
+	if err != nil {
+		return res, err
+	}
+
+	user, err := c.userRepo.FindByID(ctx, photo.UserID)
+	if err != nil {
+		c.logger.Error("Photos [REPLACE FILE]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return res, helpers.NewResponseError(helpers.ErrEmailNotVerified, http.StatusForbidden)
+	}
+
+	stripMode, keepOriginal := user.StripMetadata, user.KeepOriginal
+	if data.StripMetadata != "" {
+		stripMode = data.StripMetadata
+	}
+	if data.KeepOriginal != nil {
+		keepOriginal = *data.KeepOriginal
+	}
+
+	// replaced files get a name of their own, so earlier versions stay where they are.
+	name := fmt.Sprintf("%s/%s", photo.ID, uuid.NewString())
+	file, guards, err := c.storeFile(ctx, "Photos [REPLACE FILE]", data.Photo, photo.UserID, photo.ID, name, stripMode, keepOriginal)
+	if err != nil {
+		return res, err
+	}
+
+	file.ID = photo.ID
+	version, err := c.repo.ReplaceFile(ctx, file, guards)
+	if err != nil {
+		c.logger.Error("Photos [REPLACE FILE]", "error", err.Error())
+		c.files.delete(ctx, guards)
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.ID = photo.ID
+	res.Version = version
+	return res, nil
+}
+
+func (c *photoController) GetVersions(ctx context.Context, id string) (helpers.PhotoVersionsResponse, error) {
+	var res helpers.PhotoVersionsResponse
+
+	photo, err := c.findOwnPhoto(ctx, id, "Photos [GET VERSIONS]")
+	if err != nil {
+		return res, err
+	}
+
+	versions, err := c.repo.FindVersions(ctx, photo.ID)
+	if err != nil {
+		c.logger.Error("Photos [GET VERSIONS]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	data := make([]dtos.PhotoVersionResponse, 0, len(versions)+1)
+	data = append(data, dtos.PhotoVersionResponse{
+		Version:   photo.FileVersion,
+		Current:   true,
+		PhotoPath: fileURL(photo, "", c.signer),
+		Variants:  variantURLs(photo, "", photo.Variants, c.signer),
+		Width:     photo.Width,
+		Height:    photo.Height,
+		FileSize:  photo.Size,
+	})
+	for _, version := range versions {
+		replacedAt := version.CreatedAt
+		prefix := fmt.Sprintf("/versions/%d", version.Version)
+		data = append(data, dtos.PhotoVersionResponse{
+			Version:    version.Version,
+			PhotoPath:  fileURL(photo, prefix, c.signer),
+			Variants:   variantURLs(photo, prefix, version.Variants, c.signer),
+			Width:      version.Width,
+			Height:     version.Height,
+			FileSize:   version.Size,
+			ReplacedAt: &replacedAt,
+		})
+	}
+
+	res.Versions = data
+	return res, nil
+}
+
+func (c *photoController) RestoreVersion(ctx context.Context, id string, version int) error {
+	photo, err := c.findOwnPhoto(ctx, id, "Photos [RESTORE VERSION]")
+	if err != nil {
+		return err
+	}
+
+	if photo.FileVersion == version {
+		return helpers.NewResponseError(errors.New("this version is already the current one"), http.StatusConflict)
+	}
+
+	err = c.repo.RestoreVersion(ctx, photo.ID, version)
+	if err != nil {
+		c.logger.Error("Photos [RESTORE VERSION]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("version with specified number can't be found"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *photoController) DeleteVersion(ctx context.Context, id string, version int) error {
+	photo, err := c.findOwnPhoto(ctx, id, "Photos [DELETE VERSION]")
+	if err != nil {
+		return err
+	}
+
+	if photo.FileVersion == version {
+		return helpers.NewResponseError(errors.New("the current version can't be deleted"), http.StatusConflict)
+	}
+
+	versions, err := c.repo.FindVersions(ctx, photo.ID)
+	if err != nil {
+		c.logger.Error("Photos [DELETE VERSION]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	i := slices.IndexFunc(versions, func(v models.PhotoVersion) bool { return v.Version == version })
+	if i < 0 {
+		return helpers.NewResponseError(errors.New("version with specified number can't be found"), http.StatusNotFound)
+	}
+
+	return c.deleteVersions(ctx, photo.ID, versions[i:i+1], "Photos [DELETE VERSION]")
+}
+
+func (c *photoController) PurgeVersions(ctx context.Context, id string, q dtos.PurgePhotoVersionsQuery) error {
+	photo, err := c.findOwnPhoto(ctx, id, "Photos [PURGE VERSIONS]")
+	if err != nil {
+		return err
+	}
+
+	// versions are sorted from the most recent one.
+	versions, err := c.repo.FindVersions(ctx, photo.ID)
+	if err != nil {
+		c.logger.Error("Photos [PURGE VERSIONS]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if q.Keep >= len(versions) {
+		return nil
+	}
+
+	return c.deleteVersions(ctx, photo.ID, versions[q.Keep:], "Photos [PURGE VERSIONS]")
+}
+
+// deleteVersions removes the versions from the database first, their files are only removed once nothing points at them.
+func (c *photoController) deleteVersions(ctx context.Context, photoID string, versions []models.PhotoVersion, action string) error {
+	numbers := make([]int, len(versions))
+	for i, version := range versions {
+		numbers[i] = version.Version
+	}
+
+	var paths []string
+	for _, version := range versions {
+		paths = append(paths, version.Paths()...)
+	}
+
+	deletions, err := c.repo.DeleteVersions(ctx, photoID, numbers, fileDeletions(paths...))
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	c.files.delete(ctx, deletions)
+
+	return nil
+}
+
+func (c *photoController) GetTrash(ctx context.Context) (helpers.TrashResponse, error) {
+	var res helpers.TrashResponse
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	photos, err := c.repo.FindTrash(ctx, userID)
+	if err != nil {
+		c.logger.Error("Photos [GET TRASH]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	data := make([]dtos.TrashedPhotoResponse, len(photos))
+	for i, photo := range photos {
+		data[i] = dtos.TrashedPhotoResponse{
+			PhotoResponse: photoResponse(photo, c.signer),
+			DeletedAt:     photo.DeletedAt.Time,
+			PurgeAt:       photo.DeletedAt.Time.Add(c.conf.TrashRetention),
+		}
+	}
+
+	res.Photos = data
+	return res, nil
+}
+
+func (c *photoController) Restore(ctx context.Context, id string) error {
+	photo, err := c.repo.FindTrashedByID(ctx, id)
+	if err != nil {
+		c.logger.Error("Photos [RESTORE]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("photo with specified ID can't be found in the trash"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if photo.UserID != userID {
+		return helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	err = c.repo.Restore(ctx, photo)
+	if err != nil {
+		c.logger.Error("Photos [RESTORE]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *photoController) EmptyTrash(ctx context.Context) error {
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	photos, err := c.repo.FindTrash(ctx, userID)
+	if err != nil {
+		c.logger.Error("Photos [EMPTY TRASH]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	for _, photo := range photos {
+		err = c.purge(ctx, photo)
+		if err != nil {
+			c.logger.Error("Photos [EMPTY TRASH]", "error", err.Error())
+			return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// PurgeTrash deletes for good every photo that has been in the trash for longer than the retention period.
+// it returns the number of purged photos.
+func (c *photoController) PurgeTrash(ctx context.Context) (int, error) {
+	var purged int
+
+	before := time.Now().Add(-c.conf.TrashRetention)
+	for {
+		photos, err := c.repo.FindTrashedBefore(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, photo := range photos {
+			err = c.purge(ctx, photo)
+			if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(photos) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// purge deletes the photo row, then every file of the photo: current file, original, variants and previous versions.
+func (c *photoController) purge(ctx context.Context, photo models.Photo) error {
+	deletions, err := c.repo.Purge(ctx, photo, fileDeletions(photoPaths(photo)...))
+	if err != nil {
+		return err
+	}
+
+	c.files.delete(ctx, deletions)
+
+	return nil
+}
+
+// StripLegacyFiles strips the metadata of the files stored before stripping existed, with the current settings of
+// their owner. a photo that fails is logged and left for the next run. it returns the number of stripped photos.
+func (c *photoController) StripLegacyFiles(ctx context.Context) (int, error) {
+	var stripped int
+
+	var lastID string
+	for {
+		photos, err := c.repo.FindUnstripped(ctx, lastID, purgeBatchSize)
+		if err != nil {
+			return stripped, err
+		}
+
+		for _, photo := range photos {
+			lastID = photo.ID
+			err = c.stripLegacyFile(ctx, photo)
+			if err != nil {
+				c.logger.Error("Photos [STRIP LEGACY FILES]", "photo_id", photo.ID, "error", err.Error())
+				continue
+			}
+			stripped++
+		}
+
+		if len(photos) < purgeBatchSize {
+			return stripped, nil
+		}
+	}
+}
+
+// stripLegacyFile stores a stripped copy of the served file of a photo, and of the original when the owner keeps them.
+// the copy gets a name and a file version of its own, so no URL cached with the old file serves it anymore.
+func (c *photoController) stripLegacyFile(ctx context.Context, photo models.Photo) error {
+	mode := photo.User.StripMetadata
+	if mode == "" {
+		mode = helpers.StripGPS
+	}
+
+	data := photo
+	data.StripMetadata = mode
+	if mode == helpers.StripNone {
+		_, err := c.repo.StripFile(ctx, photo, data, nil, nil)
+		return err
+	}
+
+	file, obj, err := c.store.Get(ctx, photo.PhotoPath)
+	if err != nil {
+		return err
+	}
+	raw, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	ext := path.Ext(photo.PhotoPath)
+	name := fmt.Sprintf("%s/%s", photo.ID, uuid.NewString())
+	guards, err := c.files.guard(ctx, fmt.Sprintf("/%s/%s", photo.UserID, name), fmt.Sprintf("/originals/%s/%s", photo.UserID, name))
+	if err != nil {
+		return err
+	}
+
+	served := helpers.StripMetadata(raw, mode)
+	data.PhotoPath = fmt.Sprintf("/%s/%s%s", photo.UserID, name, ext)
+	data.Size = int64(len(served))
+	data.Checksum = helpers.Checksum(served)
+	data.FileVersion = max(photo.LastFileVersion, photo.FileVersion) + 1
+	data.LastFileVersion = data.FileVersion
+	err = c.store.Put(ctx, data.PhotoPath, bytes.NewReader(served), data.Size, obj.ContentType)
+	if err != nil {
+		c.files.delete(ctx, guards)
+		return err
+	}
+
+	if photo.User.KeepOriginal {
+		data.OriginalPath = fmt.Sprintf("/originals/%s/%s%s", photo.UserID, name, ext)
+		err = c.store.Put(ctx, data.OriginalPath, bytes.NewReader(raw), int64(len(raw)), obj.ContentType)
+		if err != nil {
+			c.files.delete(ctx, guards)
+			return err
+		}
+	}
+
+	deletions, err := c.repo.StripFile(ctx, photo, data, guards, fileDeletions(photo.PhotoPath))
+	if err != nil {
+		c.files.delete(ctx, guards)
+		return err
+	}
+
+	c.files.delete(ctx, deletions)
+
+	return nil
+}
+
+// findVisible finds a photo the current user can see, either on their own or through the share link of the request.
+func (c *photoController) findVisible(ctx context.Context, id string, metered bool) (models.Photo, error) {
+	photo, err := c.repo.FindByID(ctx, id)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return photo, err
+	}
+
+	// the photo is looked up before the link, so uses are only recorded for photos that exist.
+	photo, err = c.repo.FindSharedByID(ctx, id)
+	if err != nil {
+		return photo, err
+	}
+
+	ok, err := c.shares.authorize(ctx, id, "", metered)
+	if err != nil {
+		return photo, err
+	}
+	if !ok {
+		return photo, gorm.ErrRecordNotFound
+	}
+
+	return photo, nil
+}
+
+// findOwnPhoto returns the photo with the given ID, failing unless the current user owns it.
+func (c *photoController) findOwnPhoto(ctx context.Context, id, action string) (models.Photo, error) {
+	photo, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return photo, helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
+		}
+		return photo, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return photo, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if photo.UserID != userID {
+		return photo, helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	return photo, nil
+}
+
+// storeFile validates an uploaded photo and saves the served file, the original (when kept) and every variant
+// as /<userID>/<name><ext>. the files are guarded until the returned guards are released along with the commit of
+// the photo row. the returned photo only has its file related fields set.
+func (c *photoController) storeFile(ctx context.Context, action string, file *multipart.FileHeader, userID, photoID, name, stripMode string, keepOriginal bool) (models.Photo, []models.FileDeletion, error) {
+	var photo models.Photo
+
+	if c.conf.MaxBytes > 0 && file.Size > c.conf.MaxBytes {
+		return photo, nil, helpers.NewResponseError(fmt.Errorf("photo must not be larger than %d bytes", c.conf.MaxBytes), http.StatusRequestEntityTooLarge)
+	}
+
+	raw, err := readFile(file)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		return photo, nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	img, format, err := helpers.DecodeImage(raw, c.conf.MaxDimension, c.conf.MaxPixels)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, helpers.ErrUnsupportedImage) {
+			return photo, nil, helpers.NewResponseError(err, http.StatusUnsupportedMediaType)
+		}
+		return photo, nil, helpers.NewResponseError(err, http.StatusBadRequest)
+	}
+
+	// the variants are re-encoded without EXIF, so the orientation is applied to their pixels instead. the width
+	// and height stored are the ones the photo is displayed with.
+	metadata := helpers.ExtractMetadata(raw)
+	img = helpers.OrientImage(img, metadata.Orientation)
+
+	// the stored file is named after the detected format, the client provided file name is never trusted.
+	ext := format.Ext
+	filePath := fmt.Sprintf("/%s/%s%s", userID, name, ext)
+	contentType := format.ContentType
+
+	// originals live outside of the user folders, so they're never reachable through the static route.
+	var originalPath string
+	if keepOriginal && stripMode != helpers.StripNone {
+		originalPath = fmt.Sprintf("/originals/%s/%s%s", userID, name, ext)
+	}
+
+	guards, err := c.files.guard(ctx, fmt.Sprintf("/%s/%s", userID, name), fmt.Sprintf("/originals/%s/%s", userID, name))
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		return photo, nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	served := helpers.StripMetadata(raw, stripMode)
+	err = c.store.Put(ctx, filePath, bytes.NewReader(served), int64(len(served)), contentType)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		c.files.delete(ctx, guards)
+		return photo, nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if originalPath != "" {
+		err = c.store.Put(ctx, originalPath, bytes.NewReader(raw), int64(len(raw)), contentType)
+		if err != nil {
+			c.logger.Error(action, "error", err.Error())
+			c.files.delete(ctx, guards)
+			return photo, nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+	}
+
+	variants, err := c.createVariants(ctx, img, format.Name, photoID, strings.TrimSuffix(filePath, ext))
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		c.files.delete(ctx, guards)
+		return photo, nil, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	photo = models.Photo{
+		PhotoPath:     filePath,
+		Width:         img.Bounds().Dx(),
+		Height:        img.Bounds().Dy(),
+		Size:          int64(len(served)),
+		Checksum:      helpers.Checksum(served),
+		StripMetadata: stripMode,
+		OriginalPath:  originalPath,
+		Variants:      variants,
+		Metadata:      photoMetadata(photoID, metadata),
+	}
+
+	return photo, guards, nil
+}
+
+// createVariants generates a resized copy of the uploaded photo for every configured variant and saves it
+// next to the original, named <basePath>_<variant name>.
+func (c *photoController) createVariants(ctx context.Context, img image.Image, format, photoID, basePath string) ([]models.PhotoVariant, error) {
+	variants := make([]models.PhotoVariant, 0, len(c.conf.Variants))
+	for _, v := range c.conf.Variants {
+		resized := helpers.ResizeImage(img, v.MaxSize)
+
+		var buf bytes.Buffer
+		ext, contentType, err := helpers.EncodeImage(&buf, resized, format)
+		if err != nil {
+			c.removeVariants(ctx, variants)
+			return nil, err
+		}
+
+		variantPath := fmt.Sprintf("%s_%s%s", basePath, v.Name, ext)
+		checksum := helpers.Checksum(buf.Bytes())
+		err = c.store.Put(ctx, variantPath, &buf, int64(buf.Len()), contentType)
+		if err != nil {
+			c.removeVariants(ctx, variants)
+			return nil, err
+		}
+
+		variants = append(variants, models.PhotoVariant{
+			PhotoID:   photoID,
+			Name:      v.Name,
+			PhotoPath: variantPath,
+			Width:     resized.Bounds().Dx(),
+			Height:    resized.Bounds().Dy(),
+			Checksum:  checksum,
+		})
+	}
+
+	return variants, nil
+}
+
+func (c *photoController) removeVariants(ctx context.Context, variants []models.PhotoVariant) {
+	for _, variant := range variants {
+		c.removeFiles(ctx, variant.PhotoPath)
+	}
+}
+
+// removeFiles deletes files from the storage on a best effort basis, failures are only logged.
+func (c *photoController) removeFiles(ctx context.Context, filePaths ...string) {
+	for _, filePath := range filePaths {
+		if filePath == "" {
+			continue
+		}
+		err := c.store.Delete(ctx, filePath)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			c.logger.Error("Photos [REMOVE FILE]", "path", filePath, "error", err.Error())
+		}
+	}
+}
+
+func photoResponse(photo models.Photo, signer helpers.URLSigner) dtos.PhotoResponse {
+	return dtos.PhotoResponse{
+		ID:        photo.ID,
+		Title:     photo.Title,
+		Caption:   photo.Caption,
+		PhotoPath: fileURL(photo, "", signer),
+		Variants:  variantURLs(photo, "", photo.Variants, signer),
+		Tags:      tagNames(photo.Tags),
+	}
+}
+
+// tagModels never returns nil, so an empty list of tags still replaces the existing ones on update.
+func tagModels(names []string) []models.Tag {
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+
+	return tags
+}
+
+func tagNames(tags []models.Tag) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+
+	return names
+}
+
+// pageQuery turns the list query of a request into a repository query. one extra row is requested,
+// it tells whether there's another page in the requested direction.
+func pageQuery(q dtos.PhotoListQuery) (repositories.PageQuery, helpers.Cursor, error) {
+	var cursor helpers.Cursor
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+
+	query := repositories.PageQuery{
+		Limit:         min(limit, maxPageLimit) + 1,
+		Desc:          q.Sort != "oldest",
+		CreatedAfter:  q.CreatedAfter,
+		CreatedBefore: q.CreatedBefore,
+	}
+
+	if q.Cursor != "" {
+		var err error
+		cursor, err = helpers.DecodeCursor(q.Cursor)
+		if err != nil {
+			return query, cursor, helpers.NewResponseError(err, http.StatusBadRequest)
+		}
+
+		query.CursorCreatedAt, query.CursorID = cursor.CreatedAt, cursor.ID
+		// going to the previous page is going forward in the opposite order.
+		if cursor.Prev {
+			query.Desc = !query.Desc
+		}
+	}
+
+	return query, cursor, nil
+}
+
+// paginate drops the extra row requested by pageQuery, restores the requested order and builds
+// the cursors of the next and previous pages.
+func paginate(photos []models.Photo, query repositories.PageQuery, cursor helpers.Cursor, hasCursor bool) ([]models.Photo, string, string) {
+	hasMore := len(photos) >= query.Limit
+	if hasMore {
+		photos = photos[:query.Limit-1]
+	}
+
+	hasNext, hasPrev := hasMore, hasCursor
+	if cursor.Prev {
+		slices.Reverse(photos)
+		hasNext, hasPrev = hasCursor, hasMore
+	}
+
+	if len(photos) == 0 {
+		return photos, "", ""
+	}
+
+	var next, prev string
+	if hasNext {
+		last := photos[len(photos)-1]
+		next = helpers.EncodeCursor(helpers.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if hasPrev {
+		first := photos[0]
+		prev = helpers.EncodeCursor(helpers.Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Prev: true})
+	}
+
+	return photos, next, prev
+}
+
+func readFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return io.ReadAll(src)
+}
+
+func photoMetadata(photoID string, meta helpers.ImageMetadata) *models.PhotoMetadata {
+	return &models.PhotoMetadata{
+		PhotoID:      photoID,
+		CameraMake:   meta.CameraMake,
+		CameraModel:  meta.CameraModel,
+		LensModel:    meta.LensModel,
+		Software:     meta.Software,
+		FocalLength:  meta.FocalLength,
+		Aperture:     meta.Aperture,
+		ExposureTime: meta.ExposureTime,
+		ISO:          meta.ISO,
+		Orientation:  meta.Orientation,
+		TakenAt:      meta.TakenAt,
+		Latitude:     meta.Latitude,
+		Longitude:    meta.Longitude,
+		Altitude:     meta.Altitude,
+	}
+}
+
+func variantURLs(photo models.Photo, prefix string, variants []models.PhotoVariant, signer helpers.URLSigner) map[string]string {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	urls := make(map[string]string, len(variants))
+	for _, variant := range variants {
+		urls[variant.Name] = fileURL(photo, prefix+"/"+variant.Name, signer)
+	}
+
+	return urls
+}
+
+// fileURL returns the URL a file of the photo is served at, /files/<photoID> followed by suffix. URLs only hold
+// the photo ID, the stored file is resolved when it's requested. files of photos that aren't public get a signed URL,
+// so they can be loaded without an Authorization header (ex: by an <img> tag). URLs of the current file of public
+// photos carry the file version in the v query, so they change on replace and can be cached for good.
+func fileURL(photo models.Photo, suffix string, signer helpers.URLSigner) string {
+	url := "/files/" + photo.ID + suffix
+	if photo.Visibility == models.VisibilityPublic {
+		if strings.HasPrefix(suffix, "/versions/") {
+			return url
+		}
+		return url + "?v=" + strconv.Itoa(photo.FileVersion)
+	}
+
+	return signer.Sign(url, time.Now())
+}
+
+// photoETag returns the ETag of a photo at its current version. the username of the owner is part of the response
+// but can change without the photo, so it's tagged too. the owner gets the visibility along with the photo, so
+// their copy is tagged apart.
+func photoETag(photo models.Photo, owner bool) string {
+	return helpers.ETag(photo.ID, photo.Version, photo.User.Username, owner)
+}
+
+// pageETag returns the ETag of a page of photos along with whether they're all public. the tag is built from the
+// photos of the page and the values linking to the pages around it, such as cursors.
+func pageETag(photos []models.Photo, values ...any) (string, bool) {
+	public := true
+	for _, photo := range photos {
+		values = append(values, photo.ID, photo.Version, photo.User.Username)
+		public = public && photo.Visibility == models.VisibilityPublic
+	}
+
+	return helpers.ETag(values...), public
+}
+
+// photoFile returns the stored path and checksum of the served file of a photo or of one of its variants, for the
+// current file when version is 0 and for a previous one otherwise.
+func photoFile(photo models.Photo, variant string, version int) (string, string, bool) {
+	filePath, checksum, variants := photo.PhotoPath, photo.Checksum, photo.Variants
+	if version != 0 && version != photo.FileVersion {
+		i := slices.IndexFunc(photo.Versions, func(v models.PhotoVersion) bool { return v.Version == version })
+		if i < 0 {
+			return "", "", false
+		}
+		filePath, checksum, variants = photo.Versions[i].PhotoPath, photo.Versions[i].Checksum, photo.Versions[i].Variants
+	}
+
+	if variant == "" {
+		return filePath, checksum, true
+	}
+
+	for _, v := range variants {
+		if v.Name == variant {
+			return v.PhotoPath, v.Checksum, true
+		}
+	}
+
+	return "", "", false
+}
+
+// legacyFileSuffix finds which file of a photo is stored at filePath, it returns the suffix of its URL after /files/<photoID>.
+func legacyFileSuffix(photo models.Photo, filePath string) (string, bool) {
+	match := func(prefix, stored string, variants []models.PhotoVariant) (string, bool) {
+		if stored == filePath {
+			return prefix, true
+		}
+		for _, variant := range variants {
+			if variant.PhotoPath == filePath {
+				return prefix + "/" + variant.Name, true
+			}
+		}
+		return "", false
+	}
+
+	if suffix, ok := match("", photo.PhotoPath, photo.Variants); ok {
+		return suffix, true
+	}
+	for _, version := range photo.Versions {
+		if suffix, ok := match(fmt.Sprintf("/versions/%d", version.Version), version.PhotoPath, version.Variants); ok {
+			return suffix, true
+		}
+	}
+
+	return "", false
 }
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"photo-app/dtos"
+	"photo-app/helpers"
+	"photo-app/models"
+	"photo-app/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShareController interface {
+	Create(context.Context, dtos.CreateShareLinkRequest) (dtos.CreateShareLinkResponse, error)
+	GetMine(context.Context) (helpers.ShareLinksResponse, error)
+	Revoke(context.Context, string) error
+	GetUses(context.Context, string) (helpers.ShareLinkUsesResponse, error)
+}
+
+// shareVisitDuration is how long the requests made through a share link from the same IP count as a single view.
+const shareVisitDuration = 30 * time.Minute
+
+type shareController struct {
+	repo      repositories.ShareRepository
+	photoRepo repositories.PhotoRepository
+	albumRepo repositories.AlbumRepository
+	logger    *slog.Logger
+}
+
+func NewShareController(repo repositories.ShareRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, logger *slog.Logger) ShareController {
+	return &shareController{repo, photoRepo, albumRepo, logger}
+}
+
+func (c *shareController) Create(ctx context.Context, data dtos.CreateShareLinkRequest) (dtos.CreateShareLinkResponse, error) {
+	var res dtos.CreateShareLinkResponse
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if (data.PhotoID == "") == (data.AlbumID == "") {
+		return res, helpers.NewResponseError(errors.New("either photo_id or album_id is required"), http.StatusBadRequest)
+	}
+	if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return res, helpers.NewResponseError(errors.New("expires_at must be in the future"), http.StatusBadRequest)
+	}
+
+	link := models.ShareLink{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		ExpiresAt: data.ExpiresAt,
+		MaxViews:  data.MaxViews,
+	}
+
+	if data.PhotoID != "" {
+		photo, err := c.photoRepo.FindByID(ctx, data.PhotoID)
+		if err != nil {
+			c.logger.Error("Shares [CREATE]", "error", err.Error())
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return res, helpers.NewResponseError(errors.New("photo with specified ID can't be found"), http.StatusNotFound)
+			}
+			return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+		if photo.UserID != userID {
+			return res, helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+		}
+		link.PhotoID = &photo.ID
+	} else {
+		album, err := c.albumRepo.FindByID(ctx, data.AlbumID)
+		if err != nil {
+			c.logger.Error("Shares [CREATE]", "error", err.Error())
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return res, helpers.NewResponseError(errors.New("album with specified ID can't be found"), http.StatusNotFound)
+			}
+			return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+		if album.UserID != userID {
+			return res, helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+		}
+		link.AlbumID = &album.ID
+	}
+
+	token, tokenHash, err := helpers.NewToken()
+	if err != nil {
+		c.logger.Error("Shares [CREATE]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+	link.TokenHash = tokenHash
+
+	if data.Password != "" {
+		h, err := helpers.HashPassword([]byte(data.Password))
+		if err != nil {
+			c.logger.Error("Shares [CREATE]", "error", err.Error())
+			return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+		link.PasswordHash = string(h)
+	}
+
+	linkID, err := c.repo.Create(ctx, link)
+	if err != nil {
+		c.logger.Error("Shares [CREATE]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.ID = linkID
+	res.Token = token
+	return res, nil
+}
+
+func (c *shareController) GetMine(ctx context.Context) (helpers.ShareLinksResponse, error) {
+	var res helpers.ShareLinksResponse
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	links, err := c.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		c.logger.Error("Shares [GET MINE]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.ShareLinks = make([]dtos.ShareLinkResponse, len(links))
+	for i, link := range links {
+		res.ShareLinks[i] = shareLinkResponse(link)
+	}
+
+	return res, nil
+}
+
+func (c *shareController) Revoke(ctx context.Context, id string) error {
+	link, err := c.findOwnLink(ctx, id, "Shares [REVOKE]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.Revoke(ctx, link)
+	if err != nil {
+		c.logger.Error("Shares [REVOKE]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *shareController) GetUses(ctx context.Context, id string) (helpers.ShareLinkUsesResponse, error) {
+	var res helpers.ShareLinkUsesResponse
+
+	link, err := c.findOwnLink(ctx, id, "Shares [GET USES]")
+	if err != nil {
+		return res, err
+	}
+
+	uses, err := c.repo.FindUses(ctx, link.ID)
+	if err != nil {
+		c.logger.Error("Shares [GET USES]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.Uses = make([]dtos.ShareLinkUseResponse, len(uses))
+	for i, use := range uses {
+		res.Uses[i] = dtos.ShareLinkUseResponse{
+			PhotoID:   use.PhotoID,
+			IP:        use.IP,
+			UserAgent: use.UserAgent,
+			Counted:   use.Counted,
+			CreatedAt: use.CreatedAt,
+		}
+	}
+
+	return res, nil
+}
+
+// authorize checks the share link of the request against a photo, or against an album when photoID is empty,
+// and records its use. metered uses count a view of the link once per visit, file requests aren't metered.
+// it returns false when the request has no share link or when its link doesn't cover the target.
+func (c *shareController) authorize(ctx context.Context, photoID, albumID string, metered bool) (bool, error) {
+	access, ok := ctx.Value("share").(dtos.ShareAccess)
+	if !ok {
+		return false, nil
+	}
+
+	link, err := c.repo.FindByToken(ctx, helpers.HashToken(access.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		c.logger.Error("Shares [AUTHORIZE]", "error", err.Error())
+		return false, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	covered := false
+	switch {
+	case link.PhotoID != nil:
+		covered = photoID != "" && *link.PhotoID == photoID
+	case link.AlbumID != nil && photoID == "":
+		covered = *link.AlbumID == albumID
+	case link.AlbumID != nil:
+		covered, err = c.repo.HasAlbumPhoto(ctx, *link.AlbumID, photoID)
+		if err != nil {
+			c.logger.Error("Shares [AUTHORIZE]", "error", err.Error())
+			return false, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+	}
+	if !covered {
+		return false, nil
+	}
+
+	if link.PasswordHash != "" && helpers.ComparePassword([]byte(link.PasswordHash), []byte(access.Password)) != nil {
+		return false, helpers.NewResponseError(errors.New("share link password is missing or wrong"), http.StatusUnauthorized)
+	}
+
+	err = c.repo.Use(ctx, link, models.ShareLinkUse{
+		PhotoID:   photoID,
+		IP:        access.IP,
+		UserAgent: access.UserAgent,
+	}, metered, time.Now().Add(-shareVisitDuration))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, helpers.NewResponseError(errors.New("share link has expired, has been revoked or has no views left"), http.StatusGone)
+		}
+		c.logger.Error("Shares [AUTHORIZE]", "error", err.Error())
+		return false, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return true, nil
+}
+
+// findOwnLink returns the share link with the given ID, failing unless the current user created it.
+func (c *shareController) findOwnLink(ctx context.Context, id, action string) (models.ShareLink, error) {
+	link, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return link, helpers.NewResponseError(errors.New("share link with specified ID can't be found"), http.StatusNotFound)
+		}
+		return link, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if link.UserID != ctx.Value("id") {
+		return link, helpers.NewResponseError(helpers.ErrNotAllowed, http.StatusUnauthorized)
+	}
+
+	return link, nil
+}
+
+func shareLinkResponse(link models.ShareLink) dtos.ShareLinkResponse {
+	res := dtos.ShareLinkResponse{
+		ID:          link.ID,
+		HasPassword: link.PasswordHash != "",
+		ExpiresAt:   link.ExpiresAt,
+		MaxViews:    link.MaxViews,
+		Views:       link.Views,
+		RevokedAt:   link.RevokedAt,
+		CreatedAt:   link.CreatedAt,
+	}
+	if link.PhotoID != nil {
+		res.PhotoID = *link.PhotoID
+	}
+	if link.AlbumID != nil {
+		res.AlbumID = *link.AlbumID
+	}
+
+	return res
+}
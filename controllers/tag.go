@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"photo-app/dtos"
+	"photo-app/helpers"
+	"photo-app/repositories"
+)
+
+type TagController interface {
+	GetAll(context.Context, dtos.TagListQuery) (helpers.TagsResponse, error)
+	GetPhotos(context.Context, string, dtos.PhotoListQuery) (helpers.PhotosResponse, error)
+}
+
+const (
+	defaultTagLimit = 50
+	maxTagLimit     = 100
+)
+
+type tagController struct {
+	repo   repositories.TagRepository
+	signer helpers.URLSigner
+	logger *slog.Logger
+}
+
+func NewTagController(repo repositories.TagRepository, signer helpers.URLSigner, logger *slog.Logger) TagController {
+	return &tagController{repo, signer, logger}
+}
+
+func (c *tagController) GetAll(ctx context.Context, q dtos.TagListQuery) (helpers.TagsResponse, error) {
+	var res helpers.TagsResponse
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultTagLimit
+	}
+
+	tags, err := c.repo.FindAll(ctx, min(limit, maxTagLimit))
+	if err != nil {
+		c.logger.Error("Tags [GET ALL]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	data := make([]dtos.TagResponse, len(tags))
+	for i, tag := range tags {
+		data[i] = dtos.TagResponse{
+			Name:       tag.Name,
+			PhotoCount: tag.Count,
+		}
+	}
+
+	res.Tags = data
+	return res, nil
+}
+
+func (c *tagController) GetPhotos(ctx context.Context, tag string, q dtos.PhotoListQuery) (helpers.PhotosResponse, error) {
+	var res helpers.PhotosResponse
+
+	tag = helpers.NormalizeTag(tag)
+	if tag == "" {
+		return res, helpers.NewResponseError(errors.New("tag can't be empty"), http.StatusBadRequest)
+	}
+
+	query, cursor, err := pageQuery(q)
+	if err != nil {
+		return res, err
+	}
+
+	photos, err := c.repo.FindPhotos(ctx, tag, query)
+	if err != nil {
+		c.logger.Error("Tags [GET PHOTOS]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	photos, res.NextCursor, res.PrevCursor = paginate(photos, query, cursor, q.Cursor != "")
+
+	data := make([]dtos.PhotoResponse, len(photos))
+	for i, photo := range photos {
+		data[i] = photoResponse(photo, c.signer)
+		data[i].Owner = &dtos.UserResponse{
+			Usename: photo.User.Username,
+		}
+	}
+
+	res.Photos = data
+	return res, nil
+}
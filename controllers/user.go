@@ -5,10 +5,14 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"photo-app/dtos"
 	"photo-app/helpers"
+	"photo-app/mailer"
 	"photo-app/models"
 	"photo-app/repositories"
+	"photo-app/storage"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -18,17 +22,39 @@ import (
 type UserController interface {
 	Register(context.Context, dtos.UserRegister) (dtos.RegisterResponse, error)
 	Login(context.Context, dtos.UserLogin) (dtos.LoginResponse, error)
-	Update(context.Context, dtos.UserUpdateRequest) error
+	VerifyEmail(context.Context, dtos.VerifyEmailRequest) (dtos.VerifyEmailResponse, error)
+	ResendVerification(context.Context) error
+	ForgotPassword(context.Context, dtos.ForgotPasswordRequest) error
+	ResetPassword(context.Context, dtos.ResetPasswordRequest) error
+	Refresh(context.Context, dtos.RefreshRequest) (dtos.LoginResponse, error)
+	Logout(context.Context, dtos.LogoutRequest) error
+	GetMe(context.Context) (dtos.UserDetailResponse, error)
+	Update(context.Context, dtos.UserUpdateRequest, string) error
+	UpdateSettings(context.Context, dtos.UserSettingsRequest) error
 	Delete(context.Context) error
+	GetSessions(context.Context) (helpers.SessionsResponse, error)
+	DeleteSession(context.Context, string) error
+	Follow(context.Context, string) error
+	Unfollow(context.Context, string) error
 }
 
+// passwordResetTimeout bounds the password reset email sent in the background.
+const passwordResetTimeout = time.Minute
+
 type userController struct {
-	repo   repositories.UserRepository
-	logger *slog.Logger
+	repo     repositories.UserRepository
+	sessions repositories.SessionRepository
+	files    *fileController
+	email    *emailController
+	verifier helpers.EmailVerifier
+	conf     helpers.Auth
+	logger   *slog.Logger
 }
 
-func NewUserController(repo repositories.UserRepository, logger *slog.Logger) UserController {
-	return &userController{repo, logger}
+func NewUserController(repo repositories.UserRepository, sessionRepo repositories.SessionRepository, fileRepo repositories.FileRepository, store storage.Storage, mail mailer.Mailer, conf helpers.Auth, mailConf helpers.Mail, logger *slog.Logger) UserController {
+	files := &fileController{fileRepo, store, logger}
+	email := &emailController{mail, mailConf}
+	return &userController{repo, sessionRepo, files, email, helpers.NewEmailVerifier(conf), conf, logger}
 }
 
 func (c *userController) Register(ctx context.Context, data dtos.UserRegister) (dtos.RegisterResponse, error) {
@@ -58,9 +84,203 @@ func (c *userController) Register(ctx context.Context, data dtos.UserRegister) (
 
 	res.ID = userID
 
+	// the account exists either way, the user can ask for the email again if it didn't go out.
+	err = c.sendVerification(ctx, user, 0)
+	if err != nil {
+		c.logger.Error("User [REGISTER]", "error", err.Error())
+	}
+
+	return res, nil
+}
+
+// VerifyEmail verifies the email of the user a verification token was sent to. the token only works for the address
+// it was sent to and only once.
+func (c *userController) VerifyEmail(ctx context.Context, data dtos.VerifyEmailRequest) (dtos.VerifyEmailResponse, error) {
+	var res dtos.VerifyEmailResponse
+
+	userID, email, err := c.verifier.Verify(data.Token, time.Now())
+	if err != nil {
+		return res, helpers.NewResponseError(err, http.StatusBadRequest)
+	}
+
+	user, err := c.repo.FindByID(ctx, userID)
+	if err != nil {
+		c.logger.Error("User [VERIFY EMAIL]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(helpers.ErrInvalidVerificationToken, http.StatusBadRequest)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	// the user has changed their email since the token was sent.
+	if user.Email != email {
+		return res, helpers.NewResponseError(helpers.ErrInvalidVerificationToken, http.StatusBadRequest)
+	}
+	if user.EmailVerifiedAt != nil {
+		return res, helpers.NewResponseError(errors.New("email is already verified"), http.StatusConflict)
+	}
+
+	now := time.Now()
+	err = c.repo.MarkVerified(ctx, user.ID, email, now)
+	if err != nil {
+		c.logger.Error("User [VERIFY EMAIL]", "error", err.Error())
+		// the token was used by a concurrent request.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("email is already verified"), http.StatusConflict)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res = dtos.VerifyEmailResponse{
+		ID:              user.ID,
+		Email:           user.Email,
+		EmailVerifiedAt: now,
+	}
+
 	return res, nil
 }
 
+// ResendVerification sends the verification email of the current user again, at most once per resend interval.
+func (c *userController) ResendVerification(ctx context.Context) error {
+	id, ok := ctx.Value("id").(string)
+	if !ok {
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	user, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error("User [RESEND VERIFICATION]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return helpers.NewResponseError(errors.New("email is already verified"), http.StatusConflict)
+	}
+
+	err = c.sendVerification(ctx, user, c.conf.VerificationResendInterval)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("verification email has been sent recently, please wait before asking again"), http.StatusTooManyRequests)
+		}
+		c.logger.Error("User [RESEND VERIFICATION]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// sendVerification emails a verification link to the user, unless one has been sent less than interval ago. it
+// returns gorm.ErrRecordNotFound when it's too early or the user is already verified.
+func (c *userController) sendVerification(ctx context.Context, user models.User, interval time.Duration) error {
+	now := time.Now()
+	err := c.repo.MarkVerificationSent(ctx, user.ID, now, now.Add(-interval))
+	if err != nil {
+		return err
+	}
+
+	link := c.email.link("/api/v1/users/verify", url.Values{"token": {c.verifier.Token(user.ID, user.Email, now)}})
+	return c.email.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Please verify your email by opening the link below:\n\n" + link + "\n\n" +
+			"If you didn't sign up, you can ignore this email.\n",
+	})
+}
+
+// ForgotPassword emails a password reset token to the user with the given email. it succeeds whether the user
+// exists or not, so it can't be used to find out which emails are registered.
+func (c *userController) ForgotPassword(ctx context.Context, data dtos.ForgotPasswordRequest) error {
+	user, err := c.repo.FindByEmail(ctx, data.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		c.logger.Error("User [FORGOT PASSWORD]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	// the email is sent in the background, so the request takes as long whether the user exists or not.
+	go c.sendPasswordReset(user)
+
+	return nil
+}
+
+// sendPasswordReset creates a password reset of the user and emails its token, unless one has been sent recently.
+func (c *userController) sendPasswordReset(user models.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordResetTimeout)
+	defer cancel()
+
+	token, hash, err := helpers.NewToken()
+	if err != nil {
+		c.logger.Error("User [FORGOT PASSWORD]", "error", err.Error())
+		return
+	}
+
+	now := time.Now()
+	reset := models.PasswordReset{TokenHash: hash, UserID: user.ID, ExpiresAt: now.Add(c.conf.PasswordResetTTL)}
+	err = c.repo.CreatePasswordReset(ctx, reset, now.Add(-c.conf.PasswordResetInterval))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.logger.Warn("User [FORGOT PASSWORD]", "error", "password reset requested too often", "user_id", user.ID)
+			return
+		}
+		c.logger.Error("User [FORGOT PASSWORD]", "error", err.Error())
+		return
+	}
+
+	// the page is served by the client app, it sends the token along with the new password to /users/password/reset.
+	link := c.email.link("/reset-password", url.Values{"token": {token}})
+	err = c.email.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone asked to reset the password of your account. You can choose a new one by opening the link below, " +
+			"it can only be used once and expires shortly:\n\n" + link + "\n\n" +
+			"If it wasn't you, you can ignore this email, your password stays the same.\n",
+	})
+	if err != nil {
+		c.logger.Error("User [FORGOT PASSWORD]", "error", err.Error())
+	}
+}
+
+// ResetPassword sets a new password with the token of a password reset email, every session of the user is signed
+// out along.
+func (c *userController) ResetPassword(ctx context.Context, data dtos.ResetPasswordRequest) error {
+	errInvalidToken := helpers.NewResponseError(errors.New("invalid or expired password reset token"), http.StatusBadRequest)
+
+	reset, err := c.repo.FindPasswordReset(ctx, helpers.HashToken(data.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidToken
+		}
+		c.logger.Error("User [RESET PASSWORD]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return errInvalidToken
+	}
+
+	h, err := helpers.HashPassword([]byte(data.NewPassword))
+	if err != nil {
+		c.logger.Error("User [RESET PASSWORD]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	err = c.repo.ResetPassword(ctx, reset, string(h))
+	if err != nil {
+		// the token was used by a concurrent request.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidToken
+		}
+		c.logger.Error("User [RESET PASSWORD]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
 func (c *userController) Login(ctx context.Context, data dtos.UserLogin) (dtos.LoginResponse, error) {
 	var res dtos.LoginResponse
 
@@ -80,16 +300,233 @@ func (c *userController) Login(ctx context.Context, data dtos.UserLogin) (dtos.L
 		return res, helpers.NewResponseError(errors.New("incorrect email/password"), http.StatusUnauthorized)
 	}
 
-	res.Token, err = helpers.GenerateJWT(user.ID)
+	now := time.Now()
+	session := models.Session{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		UserAgent:  data.UserAgent,
+		IP:         data.IP,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(c.conf.RefreshTokenTTL),
+	}
+
+	refreshToken, hash, err := helpers.NewToken()
 	if err != nil {
 		c.logger.Error("User [LOGIN]", "error", err.Error())
 		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
+	err = c.sessions.Create(ctx, session, models.RefreshToken{TokenHash: hash, ExpiresAt: session.ExpiresAt})
+	if err != nil {
+		c.logger.Error("User [LOGIN]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res, err = c.loginResponse(user.ID, session.ID, refreshToken)
+	if err != nil {
+		c.logger.Error("User [LOGIN]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return res, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token. a refresh token can only be
+// exchanged once, using it again means it has leaked, so the whole session is signed out.
+func (c *userController) Refresh(ctx context.Context, data dtos.RefreshRequest) (dtos.LoginResponse, error) {
+	var res dtos.LoginResponse
+
+	token, err := c.sessions.FindToken(ctx, helpers.HashToken(data.RefreshToken))
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("invalid refresh token"), http.StatusUnauthorized)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	session, err := c.sessions.FindByID(ctx, token.SessionID)
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if session.RevokedAt != nil {
+		return res, helpers.NewResponseError(errors.New("session has been signed out, please login again"), http.StatusUnauthorized)
+	}
+	if token.UsedAt != nil {
+		return res, c.revokeReused(ctx, session)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return res, helpers.NewResponseError(errors.New("refresh token has expired, please login again"), http.StatusUnauthorized)
+	}
+
+	refreshToken, hash, err := helpers.NewToken()
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	err = c.sessions.Rotate(ctx, token, models.RefreshToken{TokenHash: hash, ExpiresAt: time.Now().Add(c.conf.RefreshTokenTTL)})
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		// the token was exchanged by a concurrent request.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, c.revokeReused(ctx, session)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res, err = c.loginResponse(session.UserID, session.ID, refreshToken)
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return res, nil
+}
+
+// Logout signs out the session of a refresh token, unknown tokens are ignored.
+func (c *userController) Logout(ctx context.Context, data dtos.LogoutRequest) error {
+	token, err := c.sessions.FindToken(ctx, helpers.HashToken(data.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		c.logger.Error("User [LOGOUT]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	err = c.sessions.Revoke(ctx, token.SessionID)
+	if err != nil {
+		c.logger.Error("User [LOGOUT]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *userController) GetSessions(ctx context.Context) (helpers.SessionsResponse, error) {
+	var res helpers.SessionsResponse
+
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	sessions, err := c.sessions.FindByUserID(ctx, userID)
+	if err != nil {
+		c.logger.Error("User [GET SESSIONS]", "error", err.Error())
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res.Sessions = make([]dtos.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		res.Sessions[i] = dtos.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID == ctx.Value("sid"),
+		}
+	}
+
+	return res, nil
+}
+
+// DeleteSession signs out one of the sessions of the current user, it can be the current one.
+func (c *userController) DeleteSession(ctx context.Context, id string) error {
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	session, err := c.sessions.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error("User [DELETE SESSION]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("session can't be found"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	// sessions of other users are reported as missing, so their IDs can't be probed.
+	if session.UserID != userID {
+		return helpers.NewResponseError(errors.New("session can't be found"), http.StatusNotFound)
+	}
+
+	err = c.sessions.Revoke(ctx, session.ID)
+	if err != nil {
+		c.logger.Error("User [DELETE SESSION]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// revokeReused signs out a session whose refresh token has been used twice.
+func (c *userController) revokeReused(ctx context.Context, session models.Session) error {
+	c.logger.Warn("User [REFRESH]", "error", "refresh token reused", "session_id", session.ID, "user_id", session.UserID)
+
+	err := c.sessions.Revoke(ctx, session.ID)
+	if err != nil {
+		c.logger.Error("User [REFRESH]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return helpers.NewResponseError(errors.New("refresh token has already been used, the session has been signed out"), http.StatusUnauthorized)
+}
+
+// loginResponse returns a new access token of the session along with its refresh token.
+func (c *userController) loginResponse(userID, sessionID, refreshToken string) (dtos.LoginResponse, error) {
+	token, err := helpers.GenerateJWT(userID, sessionID, c.conf.AccessTokenTTL)
+	if err != nil {
+		return dtos.LoginResponse{}, err
+	}
+
+	return dtos.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(c.conf.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (c *userController) GetMe(ctx context.Context) (dtos.UserDetailResponse, error) {
+	var res dtos.UserDetailResponse
+
+	id, ok := ctx.Value("id").(string)
+	if !ok {
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	user, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error("User [GET ME]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helpers.NewResponseError(errors.New("user can't be found"), http.StatusNotFound)
+		}
+		return res, helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	res = dtos.UserDetailResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		StripMetadata: user.StripMetadata,
+		KeepOriginal:  user.KeepOriginal,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		ETag:          userETag(user),
+	}
+
 	return res, nil
 }
 
-func (c *userController) Update(ctx context.Context, data dtos.UserUpdateRequest) error {
+// Update updates data of the current user, ifMatch is the If-Match header of the request and must be the current
+// ETag of the user when it's set.
+func (c *userController) Update(ctx context.Context, data dtos.UserUpdateRequest, ifMatch string) error {
 	id, ok := ctx.Value("id").(string)
 	if !ok {
 		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
@@ -111,6 +548,16 @@ func (c *userController) Update(ctx context.Context, data dtos.UserUpdateRequest
 		return helpers.NewResponseError(errors.New("invalid password"), http.StatusUnauthorized)
 	}
 
+	if !helpers.IfMatch(ifMatch, userETag(user)) {
+		return helpers.NewResponseError(helpers.ErrPreconditionFailed, http.StatusPreconditionFailed)
+	}
+
+	// a new email has to be verified again.
+	emailChanged := data.Email != "" && data.Email != user.Email
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
+
 	// if the value doesn't change, gorm will automatically handles it (not updating the data).
 	user.Email = data.Email
 	if data.NewPassword != "" {
@@ -126,6 +573,10 @@ func (c *userController) Update(ctx context.Context, data dtos.UserUpdateRequest
 	err = c.repo.Update(ctx, user)
 	if err != nil {
 		c.logger.Error("User [UPDATE]", "error", err.Error())
+		// the user was found above, so it has been changed by another request since.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(helpers.ErrPreconditionFailed, http.StatusPreconditionFailed)
+		}
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
 			return helpers.NewResponseError(errors.New("user with provided username/email already exist"), http.StatusConflict)
@@ -133,6 +584,51 @@ func (c *userController) Update(ctx context.Context, data dtos.UserUpdateRequest
 		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
+	if data.NewPassword != "" && data.SignOutOthers {
+		sessionID, _ := ctx.Value("sid").(string)
+		err = c.sessions.RevokeOthers(ctx, user.ID, sessionID)
+		if err != nil {
+			c.logger.Error("User [UPDATE]", "error", err.Error())
+			return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+		}
+	}
+
+	if emailChanged {
+		err = c.sendVerification(ctx, user, 0)
+		if err != nil {
+			c.logger.Error("User [UPDATE]", "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (c *userController) UpdateSettings(ctx context.Context, data dtos.UserSettingsRequest) error {
+	id, ok := ctx.Value("id").(string)
+	if !ok {
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	user, err := c.repo.FindByID(ctx, id)
+	if err != nil {
+		c.logger.Error("User [UPDATE SETTINGS]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	toUpdate := make(map[string]any)
+	if data.StripMetadata != nil {
+		toUpdate["strip_metadata"] = *data.StripMetadata
+	}
+	if data.KeepOriginal != nil {
+		toUpdate["keep_original"] = *data.KeepOriginal
+	}
+
+	err = c.repo.UpdateSettings(ctx, user, toUpdate)
+	if err != nil {
+		c.logger.Error("User [UPDATE SETTINGS]", "error", err.Error())
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
 	return nil
 }
 
@@ -148,11 +644,85 @@ func (c *userController) Delete(ctx context.Context) error {
 		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
-	err = c.repo.Delete(ctx, user)
+	// every file of the user lives under these folders, they're removed once the user row is gone.
+	now := time.Now()
+	deletions := []models.FileDeletion{
+		{Path: "/" + user.ID + "/", Prefix: true, NotBefore: now},
+		{Path: "/originals/" + user.ID + "/", Prefix: true, NotBefore: now},
+	}
+
+	deletions, err = c.repo.Delete(ctx, user, deletions)
 	if err != nil {
 		c.logger.Error("User [DELETE]", "error", err.Error())
 		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
 	}
 
+	c.files.delete(ctx, deletions)
+
 	return nil
 }
+
+func (c *userController) Follow(ctx context.Context, username string) error {
+	followee, userID, err := c.findFollowee(ctx, username, "User [FOLLOW]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.Follow(ctx, userID, followee.ID)
+	if err != nil {
+		c.logger.Error("User [FOLLOW]", "error", err.Error())
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == "23505" {
+			return helpers.NewResponseError(errors.New("you already follow this user"), http.StatusConflict)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (c *userController) Unfollow(ctx context.Context, username string) error {
+	followee, userID, err := c.findFollowee(ctx, username, "User [UNFOLLOW]")
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.Unfollow(ctx, userID, followee.ID)
+	if err != nil {
+		c.logger.Error("User [UNFOLLOW]", "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.NewResponseError(errors.New("you don't follow this user"), http.StatusNotFound)
+		}
+		return helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// findFollowee returns the user with the given username along with the current user ID, users can't follow themselves.
+func (c *userController) findFollowee(ctx context.Context, username, action string) (models.User, string, error) {
+	userID, ok := ctx.Value("id").(string)
+	if !ok {
+		return models.User{}, "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	followee, err := c.repo.FindByUsername(ctx, username)
+	if err != nil {
+		c.logger.Error(action, "error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return followee, "", helpers.NewResponseError(errors.New("user with specified username can't be found"), http.StatusNotFound)
+		}
+		return followee, "", helpers.NewResponseError(helpers.ErrInternal, http.StatusInternalServerError)
+	}
+
+	if followee.ID == userID {
+		return followee, "", helpers.NewResponseError(errors.New("you can't follow yourself"), http.StatusBadRequest)
+	}
+
+	return followee, userID, nil
+}
+
+// userETag returns the ETag of a user at its current version.
+func userETag(user models.User) string {
+	return helpers.ETag(user.ID, user.Version)
+}
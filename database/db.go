@@ -25,10 +25,69 @@ func New(conf helpers.DB) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(models.User{}, models.Photo{})
+	// accounts created before email verification existed are taken as verified, so they can keep uploading.
+	verifyExisting := db.Migrator().HasTable(&models.User{}) && !db.Migrator().HasColumn(&models.User{}, "email_verified_at")
+
+	err = db.AutoMigrate(models.User{}, models.Photo{}, models.PhotoVariant{}, models.PhotoMetadata{}, models.Album{}, models.AlbumPhoto{}, models.Tag{}, models.PhotoVersion{}, models.FileDeletion{}, models.Follow{}, models.ShareLink{}, models.ShareLinkUse{}, models.Session{}, models.RefreshToken{}, models.PasswordReset{})
+	if err != nil {
+		return nil, err
+	}
+
+	if verifyExisting {
+		err = db.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL").Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = migrateVisibility(db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateSearch(db)
 	if err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// migrateVisibility replaces the is_private column of photos and albums with their visibility. the visibility column
+// is added by AutoMigrate with public as default, so only the private rows are left to update.
+func migrateVisibility(db *gorm.DB) error {
+	for _, model := range []any{&models.Photo{}, &models.Album{}} {
+		if !db.Migrator().HasColumn(model, "is_private") {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(model).Where("is_private").UpdateColumn("visibility", models.VisibilityPrivate).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(model, "is_private")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateSearch adds the full-text search column of photos. it's generated by PostgreSQL from the title and caption,
+// so it's left out of models.Photo and never written by the app. the 'simple' configuration is used since titles
+// and captions aren't written in a single language.
+func migrateSearch(db *gorm.DB) error {
+	err := db.Exec(`ALTER TABLE photos ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(caption, '')), 'B')
+	) STORED`).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_photos_search_vector ON photos USING GIN (search_vector)").Error
+}
@@ -0,0 +1,44 @@
+package dtos
+
+type CreateAlbumRequest struct {
+	Title        string  `json:"title" binding:"required" example:"Holiday 2023"`
+	Description  string  `json:"description" example:"Photos from our trip to Bali"`
+	CoverPhotoID *string `json:"cover_photo_id"`
+	Visibility   string  `json:"visibility" binding:"omitempty,oneof=public unlisted followers private" enums:"public,unlisted,followers,private" description:"Who can see this album, defaults to public. unlisted albums are only reachable by their link"`
+}
+
+type CreateAlbumResponse struct {
+	ID string `json:"album_id"`
+}
+
+type UpdateAlbumRequest struct {
+	Title        *string `json:"title" example:"Holiday 2023 (Bali)"`
+	Description  *string `json:"description" example:"Photos from our trip to Bali"`
+	CoverPhotoID *string `json:"cover_photo_id"`
+	Visibility   *string `json:"visibility" binding:"omitempty,oneof=public unlisted followers private" enums:"public,unlisted,followers,private" example:"unlisted"`
+}
+
+type AddAlbumPhotoRequest struct {
+	PhotoID  string `json:"photo_id" binding:"required"`
+	Position *int   `json:"position" binding:"omitempty,min=0" description:"zero-based position, the photo is added at the end if empty"`
+}
+
+type ReorderAlbumPhotosRequest struct {
+	PhotoIDs []string `json:"photo_ids" binding:"required,min=1,unique" description:"every photo of the album, in the new order"`
+}
+
+type AlbumResponse struct {
+	ID          string         `json:"album_id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Visibility  string         `json:"visibility" enums:"public,unlisted,followers,private"`
+	CoverPhoto  *PhotoResponse `json:"cover_photo,omitempty"`
+
+	Owner  *UserResponse        `json:"owner,omitempty"`
+	Photos []AlbumPhotoResponse `json:"photos,omitempty"`
+}
+
+type AlbumPhotoResponse struct {
+	Position int `json:"position"`
+	PhotoResponse
+}
@@ -0,0 +1,9 @@
+package dtos
+
+// FileReport is the result of a reconciliation between the storage and the database.
+type FileReport struct {
+	// OrphanedFiles are stored files no row references.
+	OrphanedFiles []string
+	// MissingFiles are referenced files that can't be found in the storage, keyed by path with the photo ID as value.
+	MissingFiles map[string]string
+}
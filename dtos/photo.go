@@ -2,13 +2,17 @@ package dtos
 
 import (
 	"mime/multipart"
+	"time"
 )
 
 type CreatePhotoRequest struct {
-	Title     string                `form:"title" binding:"required" example:"I'm cool"`
-	Caption   string                `form:"caption" example:"A cool photo of me"`
-	IsPrivate bool                  `form:"is_private" description:"Do you want to make this photo private?"`
-	Photo     *multipart.FileHeader `form:"photo" swaggerignore:"true"`
+	Title         string                `form:"title" binding:"required" example:"I'm cool"`
+	Caption       string                `form:"caption" example:"A cool photo of me"`
+	Visibility    string                `form:"visibility" binding:"omitempty,oneof=public unlisted followers private" enums:"public,unlisted,followers,private" description:"Who can see this photo, defaults to public. unlisted photos are only reachable by their link"`
+	StripMetadata string                `form:"strip_metadata" binding:"omitempty,oneof=none gps all" enums:"none,gps,all" description:"Metadata to remove from the served photo, defaults to your settings"`
+	KeepOriginal  *bool                 `form:"keep_original" description:"Keep the untouched file, only available to you. defaults to your settings"`
+	Tags          []string              `form:"tags" binding:"max=20" description:"Free-form tags, normalized to lowercase slugs. comma separated values are split"`
+	Photo         *multipart.FileHeader `form:"photo" swaggerignore:"true"`
 }
 
 type CreatePhotoResponse struct {
@@ -16,16 +20,116 @@ type CreatePhotoResponse struct {
 }
 
 type UpdatePhotoRequest struct {
-	Title     *string `json:"title" example:"I'm very cool"`
-	IsPrivate *bool   `json:"is_private"`
-	Caption   *string `json:"caption" example:"A very cool photo of me"`
+	Title      *string   `json:"title" example:"I'm very cool"`
+	Visibility *string   `json:"visibility" binding:"omitempty,oneof=public unlisted followers private" enums:"public,unlisted,followers,private" example:"unlisted"`
+	Caption    *string   `json:"caption" example:"A very cool photo of me"`
+	Tags       *[]string `json:"tags" binding:"omitempty,max=20" example:"selfie,beach" description:"replaces every tag of the photo, an empty list removes them"`
+}
+
+type ReplacePhotoRequest struct {
+	StripMetadata string                `form:"strip_metadata" binding:"omitempty,oneof=none gps all" enums:"none,gps,all" description:"Metadata to remove from the served photo, defaults to your settings"`
+	KeepOriginal  *bool                 `form:"keep_original" description:"Keep the untouched file, only available to you. defaults to your settings"`
+	Photo         *multipart.FileHeader `form:"photo" swaggerignore:"true"`
+}
+
+type ReplacePhotoResponse struct {
+	ID      string `json:"photo_id"`
+	Version int    `json:"version" example:"2"`
+}
+
+type PurgePhotoVersionsQuery struct {
+	Keep int `form:"keep" binding:"omitempty,min=0" example:"0" description:"number of the most recent previous versions to keep"`
+}
+
+type PhotoVersionResponse struct {
+	Version    int               `json:"version" example:"1"`
+	Current    bool              `json:"current"`
+	PhotoPath  string            `json:"photo_path"`
+	Variants   map[string]string `json:"variants,omitempty"`
+	Width      int               `json:"width,omitempty" example:"4000"`
+	Height     int               `json:"height,omitempty" example:"3000"`
+	FileSize   int64             `json:"file_size,omitempty" example:"2048576"`
+	ReplacedAt *time.Time        `json:"replaced_at,omitempty"`
+}
+
+type TrashedPhotoResponse struct {
+	PhotoResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" description:"the photo is deleted for good after this time"`
+}
+
+type PhotoListQuery struct {
+	Cursor        string    `form:"cursor" description:"next_cursor or prev_cursor of a previous response"`
+	Limit         int       `form:"limit" binding:"omitempty,min=1" example:"20"`
+	Sort          string    `form:"sort" binding:"omitempty,oneof=newest oldest" enums:"newest,oldest"`
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00" example:"2023-01-01T00:00:00Z"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00" example:"2024-01-01T00:00:00Z"`
+}
+
+type PhotoFileQuery struct {
+	Version int `form:"v" binding:"omitempty,min=1" description:"version of the current file, URLs holding it can be cached for good"`
+}
+
+// PhotoFileResponse describes a served file of a photo.
+type PhotoFileResponse struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	// ETag is the content hash of the file.
+	ETag string
+	// Public is set for files of public photos, they can be kept by shared caches.
+	Public bool
+	// Immutable is set when the requested URL always points to this file.
+	Immutable bool
+}
+
+type PhotoSearchQuery struct {
+	Query string `form:"q" binding:"required" example:"sunset beach"`
+	Limit int    `form:"limit" binding:"omitempty,min=1" example:"20"`
+	Page  int    `form:"page" binding:"omitempty,min=1" example:"1"`
 }
 
 type PhotoResponse struct {
-	ID        string `json:"photo_id"`
-	Title     string `json:"title"`
-	Caption   string `json:"caption,omitempty"`
-	PhotoPath string `json:"photo_path"`
+	ID        string            `json:"photo_id"`
+	Title     string            `json:"title"`
+	Caption   string            `json:"caption,omitempty"`
+	PhotoPath string            `json:"photo_path"`
+	Variants  map[string]string `json:"variants,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
 
 	Owner *UserResponse `json:"owner,omitempty"`
 }
+
+type PhotoDetailResponse struct {
+	PhotoResponse
+	Width      int       `json:"width,omitempty" example:"4000"`
+	Height     int       `json:"height,omitempty" example:"3000"`
+	FileSize   int64     `json:"file_size,omitempty" example:"2048576"`
+	Visibility string    `json:"visibility,omitempty" enums:"public,unlisted,followers,private" description:"only shown to the owner"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// ETag is the entity tag of the photo row, sent as a header.
+	ETag string `json:"-"`
+	// Public is set for public photos, their response doesn't hold signed URLs.
+	Public bool `json:"-"`
+}
+
+type PhotoMetadataResponse struct {
+	CameraMake   string     `json:"camera_make,omitempty" example:"Canon"`
+	CameraModel  string     `json:"camera_model,omitempty" example:"Canon EOS R5"`
+	LensModel    string     `json:"lens_model,omitempty" example:"RF24-70mm F2.8 L IS USM"`
+	Software     string     `json:"software,omitempty"`
+	FocalLength  *float64   `json:"focal_length,omitempty" example:"35"`
+	Aperture     *float64   `json:"aperture,omitempty" example:"2.8"`
+	ExposureTime string     `json:"exposure_time,omitempty" example:"1/250"`
+	ISO          *int       `json:"iso,omitempty" example:"100"`
+	Orientation  int        `json:"orientation,omitempty" example:"1"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"`
+	Latitude     *float64   `json:"latitude,omitempty"`
+	Longitude    *float64   `json:"longitude,omitempty"`
+	Altitude     *float64   `json:"altitude,omitempty"`
+
+	// ETag is the entity tag of the metadata, sent as a header.
+	ETag string `json:"-"`
+}
@@ -0,0 +1,44 @@
+package dtos
+
+import "time"
+
+// ShareAccess is the share link a request is made with, it's read by middlewares.ShareMiddleware.
+type ShareAccess struct {
+	Token     string
+	Password  string
+	IP        string
+	UserAgent string
+}
+
+type CreateShareLinkRequest struct {
+	PhotoID   string     `json:"photo_id" description:"photo to share, either photo_id or album_id is required"`
+	AlbumID   string     `json:"album_id" description:"album to share along with its photos, either photo_id or album_id is required"`
+	ExpiresAt *time.Time `json:"expires_at" description:"the link never expires if empty"`
+	Password  string     `json:"password" binding:"omitempty,min=6" description:"required to use the link if set"`
+	MaxViews  int        `json:"max_views" binding:"min=0" example:"10" description:"number of views allowed, the requests made from the same IP within 30 minutes count as one view and file requests never count. 0 means unlimited"`
+}
+
+type CreateShareLinkResponse struct {
+	ID    string `json:"share_id"`
+	Token string `json:"token" description:"only given once, send it in the X-Share-Token header or the share query parameter"`
+}
+
+type ShareLinkResponse struct {
+	ID          string     `json:"share_id"`
+	PhotoID     string     `json:"photo_id,omitempty"`
+	AlbumID     string     `json:"album_id,omitempty"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxViews    int        `json:"max_views,omitempty"`
+	Views       int        `json:"views"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ShareLinkUseResponse struct {
+	PhotoID   string    `json:"photo_id,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Counted   bool      `json:"counted" description:"whether the use counted a view, only the first use of a visit does"`
+	CreatedAt time.Time `json:"created_at"`
+}
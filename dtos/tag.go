@@ -0,0 +1,10 @@
+package dtos
+
+type TagListQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1" example:"50"`
+}
+
+type TagResponse struct {
+	Name       string `json:"tag" example:"beach"`
+	PhotoCount int64  `json:"photo_count" example:"12"`
+}
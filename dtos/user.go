@@ -1,5 +1,7 @@
 package dtos
 
+import "time"
+
 type UserRegister struct {
 	Username string `json:"username" binding:"required" example:"johndoe"`
 	Email    string `json:"email" binding:"required,email" example:"johndoe@mail.com"`
@@ -13,10 +15,43 @@ type RegisterResponse struct {
 type UserLogin struct {
 	Email    string `json:"email" binding:"required,email" example:"johndoe@mail.com"`
 	Password string `json:"password" binding:"required,min=6" example:"JohnDoe123"`
+
+	// IP and UserAgent describe the device of the new session.
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token" description:"short-lived access token, sent as Bearer token"`
+	RefreshToken string `json:"refresh_token" description:"exchanged at /users/refresh for new tokens, it can only be used once"`
+	ExpiresIn    int    `json:"expires_in" example:"900" description:"seconds until the access token expires"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type VerifyEmailResponse struct {
+	ID              string    `json:"user_id"`
+	Email           string    `json:"email" example:"johndoe@mail.com"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"johndoe@mail.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required" description:"token of the password reset email"`
+	NewPassword string `json:"new_password" binding:"required,min=6" example:"JohnDoe456"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type UserUpdateRequest struct {
@@ -24,6 +59,36 @@ type UserUpdateRequest struct {
 	Email       string `json:"email" binding:"omitempty,email" example:"johndoe@mail.org"`
 	Password    string `json:"password" binding:"required,min=6" example:"JohnDoe123"`
 	NewPassword string `json:"new_password" binding:"omitempty,min=6"`
+	// SignOutOthers only applies along with a new password.
+	SignOutOthers bool `json:"sign_out_other_sessions" description:"sign out every other session when changing the password"`
+}
+
+type UserSettingsRequest struct {
+	StripMetadata *string `json:"strip_metadata" binding:"omitempty,oneof=none gps all" enums:"none,gps,all" example:"gps"`
+	KeepOriginal  *bool   `json:"keep_original" example:"false"`
+}
+
+type UserDetailResponse struct {
+	ID            string    `json:"user_id"`
+	Username      string    `json:"username" example:"johndoe"`
+	Email         string    `json:"email" example:"johndoe@mail.com"`
+	EmailVerified bool      `json:"email_verified" description:"unverified users can't upload photos"`
+	StripMetadata string    `json:"strip_metadata" enums:"none,gps,all" example:"gps"`
+	KeepOriginal  bool      `json:"keep_original"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// ETag is the entity tag of the user row, sent as a header.
+	ETag string `json:"-"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"session_id"`
+	UserAgent  string    `json:"user_agent,omitempty" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IP         string    `json:"ip,omitempty" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current" description:"whether it's the session of the request"`
 }
 
 type UserResponse struct {
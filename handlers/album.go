@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"photo-app/controllers"
+	"photo-app/dtos"
+	"photo-app/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AlbumHandler struct {
+	c controllers.AlbumController
+}
+
+func NewAlbumHandler(c controllers.AlbumController) *AlbumHandler {
+	return &AlbumHandler{c}
+}
+
+// CreateAlbum godoc
+//
+//	@Summary		create album
+//	@Description	create an album for current user
+//	@Tags			Albums
+//	@Param			Body	body	dtos.CreateAlbumRequest	true	"data required to create new album"
+//	@Produce		json
+//	@Success		201	{object}	dtos.CreateAlbumResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		422	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums [post]
+//	@Security		Bearer
+func (h *AlbumHandler) Create(ctx *gin.Context) {
+	var data dtos.CreateAlbumRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	resp, err := h.c.Create(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// GetAlbum godoc
+//
+//	@Summary		get album
+//	@Description	get an album and its photos in order, private photos are only listed for their owner. an album shared with a link lists every one of its photos
+//	@Tags			Albums
+//	@Param			id					path	string	true	"album ID"
+//	@Param			share			query	string	false	"share link token, the X-Share-Token header can be used instead"
+//	@Param			X-Share-Password	header	string	false	"password of the share link"
+//	@Produce		json
+//	@Success		200	{object}	dtos.AlbumResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		410	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id} [get]
+//	@Security		Bearer
+func (h *AlbumHandler) GetByID(ctx *gin.Context) {
+	albumID := ctx.Param("id")
+
+	album, err := h.c.GetByID(ctx, albumID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, album)
+}
+
+// GetMyAlbums godoc
+//
+//	@Summary		get all albums of current user
+//	@Description	get all albums of current user, whatever their visibility
+//	@Tags			Albums
+//	@Produce		json
+//	@Success		200	{object}	helpers.AlbumsResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/my [get]
+//	@Security		Bearer
+func (h *AlbumHandler) GetMine(ctx *gin.Context) {
+	id := ctx.GetString("id")
+
+	albums, err := h.c.GetByUserID(ctx, id)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, albums)
+}
+
+// GetAlbumsByOwner godoc
+//
+//	@Summary		get all public albums owned by a user
+//	@Description	get all public albums owned by specified user by providing their username
+//	@Tags			Albums
+//	@Param			username	path	string	true	"owner's username"
+//	@Produce		json
+//	@Success		200	{object}	helpers.AlbumsResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/by/{username} [get]
+func (h *AlbumHandler) GetByOwner(ctx *gin.Context) {
+	username := ctx.Param("username")
+
+	albums, err := h.c.GetByOwner(ctx, username)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, albums)
+}
+
+// UpdateAlbum godoc
+//
+//	@Summary		update album
+//	@Description	update title, description, cover photo or visibility of an album, an empty cover_photo_id removes the cover
+//	@Tags			Albums
+//	@Param			id		path	string					true	"album ID"
+//	@Param			Body	body	dtos.UpdateAlbumRequest	true	"data required to update an album"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		422	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id} [put]
+//	@Security		Bearer
+func (h *AlbumHandler) Update(ctx *gin.Context) {
+	var data dtos.UpdateAlbumRequest
+	albumID := ctx.Param("id")
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.Update(ctx, data, albumID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeleteAlbum godoc
+//
+//	@Summary		delete album
+//	@Description	delete an album by given ID, the photos in it are kept
+//	@Tags			Albums
+//	@Param			id	path	string	true	"album ID"
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id} [delete]
+//	@Security		Bearer
+func (h *AlbumHandler) Delete(ctx *gin.Context) {
+	albumID := ctx.Param("id")
+
+	err := h.c.Delete(ctx, albumID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AddAlbumPhoto godoc
+//
+//	@Summary		add photo to album
+//	@Description	add one of current user's photos to an album, at the given position or at the end
+//	@Tags			Albums
+//	@Param			id		path	string						true	"album ID"
+//	@Param			Body	body	dtos.AddAlbumPhotoRequest	true	"photo to add"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		422	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id}/photos [post]
+//	@Security		Bearer
+func (h *AlbumHandler) AddPhoto(ctx *gin.Context) {
+	var data dtos.AddAlbumPhotoRequest
+	albumID := ctx.Param("id")
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.AddPhoto(ctx, data, albumID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ReorderAlbumPhotos godoc
+//
+//	@Summary		reorder album photos
+//	@Description	set the order of the photos in an album, photo_ids must list every photo of the album
+//	@Tags			Albums
+//	@Param			id		path	string							true	"album ID"
+//	@Param			Body	body	dtos.ReorderAlbumPhotosRequest	true	"photo IDs in the new order"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		422	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id}/photos [put]
+//	@Security		Bearer
+func (h *AlbumHandler) ReorderPhotos(ctx *gin.Context) {
+	var data dtos.ReorderAlbumPhotosRequest
+	albumID := ctx.Param("id")
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.ReorderPhotos(ctx, data, albumID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveAlbumPhoto godoc
+//
+//	@Summary		remove photo from album
+//	@Description	remove a photo from an album, the photo itself is kept
+//	@Tags			Albums
+//	@Param			id			path	string	true	"album ID"
+//	@Param			photo_id	path	string	true	"photo ID"
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/albums/{id}/photos/{photo_id} [delete]
+//	@Security		Bearer
+func (h *AlbumHandler) RemovePhoto(ctx *gin.Context) {
+	albumID := ctx.Param("id")
+	photoID := ctx.Param("photo_id")
+
+	err := h.c.RemovePhoto(ctx, albumID, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -5,20 +5,53 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"photo-app/controllers"
 	"photo-app/dtos"
 	"photo-app/helpers"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// multipartOverhead is allowed on top of the maximum photo size for the other fields and the multipart framing.
+const multipartOverhead = 1 << 20
+
 type PhotoHandler struct {
-	c controllers.PhotoController
+	c        controllers.PhotoController
+	maxBytes int64
+}
+
+func NewPhotoHandler(c controllers.PhotoController, conf helpers.Photo) *PhotoHandler {
+	return &PhotoHandler{c, conf.MaxBytes}
 }
 
-func NewPhotoHandler(c controllers.PhotoController) *PhotoHandler {
-	return &PhotoHandler{c}
+// limitUpload caps the size of the request body, so an oversized upload is rejected while it's read instead of
+// being parsed into memory and temporary files first.
+func (h *PhotoHandler) limitUpload(ctx *gin.Context) {
+	if h.maxBytes > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.maxBytes+multipartOverhead)
+	}
+}
+
+// notModified sets the cache headers of a JSON response and reports whether the request can be answered with 304
+// Not Modified. file URLs of photos that aren't public are signed and change every minute, so a response holding
+// them is only reused while they're the current ones, its Last-Modified being the minute they were signed.
+func notModified(ctx *gin.Context, etag string, signed bool, modTime time.Time) bool {
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", helpers.CachePrivate)
+	if signed {
+		signedAt := time.Now().Truncate(time.Minute)
+		ctx.Header("Last-Modified", signedAt.UTC().Format(http.TimeFormat))
+		return helpers.NotModifiedSigned(ctx.Request, etag, signedAt)
+	}
+
+	if !modTime.IsZero() {
+		ctx.Header("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	return helpers.NotModified(ctx.Request, etag, modTime)
 }
 
 // AddPhoto godoc
@@ -31,12 +64,23 @@ func NewPhotoHandler(c controllers.PhotoController) *PhotoHandler {
 //	@Produce		json
 //	@Success		201	{object}	dtos.CreatePhotoResponse
 //	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		403	{object}	helpers.ErrorResponse
+//	@Failure		413	{object}	helpers.ErrorResponse
+//	@Failure		415	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
 //	@Router			/photos [post]
 //	@Security		Bearer
 func (h *PhotoHandler) Create(ctx *gin.Context) {
 	var data dtos.CreatePhotoRequest
+	h.limitUpload(ctx)
 	if err := ctx.ShouldBind(&data); err != nil {
+		var errMaxBytes *http.MaxBytesError
+		if errors.As(err, &errMaxBytes) {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("photo must not be larger than %d bytes", h.maxBytes),
+			})
+			return
+		}
 		var errValidation validator.ValidationErrors
 		if errors.As(err, &errValidation) {
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
@@ -57,9 +101,10 @@ func (h *PhotoHandler) Create(ctx *gin.Context) {
 		return
 	}
 
-	if !helpers.IsImage(data.Photo.Header.Get("Content-Type")) {
+	// the file content itself is validated by the controller, the client provided content type is ignored.
+	if data.Photo == nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "photo must be an image",
+			"error": "photo can't be empty",
 		})
 		return
 	}
@@ -87,14 +132,32 @@ func (h *PhotoHandler) Create(ctx *gin.Context) {
 //	@Summary		get all public photos
 //	@Description	get all public photos
 //	@Tags			Photos
+//	@Param			query	query	dtos.PhotoListQuery	false	"pagination, sorting and filtering"
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
 //	@Produce		json
 //	@Success		200	{object}	helpers.PhotosResponse
+//	@Success		304
 //	@Failure		400	{object}	helpers.ErrorResponse
 //	@Failure		404	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
 //	@Router			/photos [get]
 func (h *PhotoHandler) GetAll(ctx *gin.Context) {
-	photos, err := h.c.GetAll(ctx)
+	var query dtos.PhotoListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.c.GetAll(ctx, query)
 	if err != nil {
 		var errController helpers.ResponseError
 		if errors.As(err, &errController) {
@@ -109,9 +172,12 @@ func (h *PhotoHandler) GetAll(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"photos": photos,
-	})
+	if notModified(ctx, photos.ETag, !photos.Public, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
 }
 
 // GetMyPhotos godoc
@@ -119,8 +185,12 @@ func (h *PhotoHandler) GetAll(ctx *gin.Context) {
 //	@Summary		get all photos of current user
 //	@Description	get all available photos of current user
 //	@Tags			Photos
+//	@Param			query	query	dtos.PhotoListQuery	false	"pagination, sorting and filtering"
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
+//	@Param			If-Modified-Since	header	string	false	"Last-Modified of a previous response, needed along with If-None-Match for pages holding photos that aren't public"
 //	@Produce		json
 //	@Success		200	{object}	helpers.PhotosResponse
+//	@Success		304
 //	@Failure		400	{object}	helpers.ErrorResponse
 //	@Failure		404	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
@@ -128,9 +198,23 @@ func (h *PhotoHandler) GetAll(ctx *gin.Context) {
 //	@Security		Bearer
 func (h *PhotoHandler) GetMine(ctx *gin.Context) {
 	id := ctx.GetString("id")
-	fmt.Println(id)
 
-	photos, err := h.c.GetByUserID(ctx, id)
+	var query dtos.PhotoListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.c.GetByUserID(ctx, id, query)
 	if err != nil {
 		var errController helpers.ResponseError
 		if errors.As(err, &errController) {
@@ -145,9 +229,12 @@ func (h *PhotoHandler) GetMine(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"photos": photos,
-	})
+	if notModified(ctx, photos.ETag, !photos.Public, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
 }
 
 // GetPhotoByOwner godoc
@@ -155,9 +242,13 @@ func (h *PhotoHandler) GetMine(ctx *gin.Context) {
 //	@Summary		get all public photo owned by a user
 //	@Description	get all public photo owned by specified user by providing their username
 //	@Tags			Photos
-//	@Param			username	path	string	true	"owner's username"
+//	@Param			username	path	string				true	"owner's username"
+//	@Param			query		query	dtos.PhotoListQuery	false	"pagination, sorting and filtering"
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
+//	@Param			If-Modified-Since	header	string	false	"Last-Modified of a previous response, needed along with If-None-Match for pages holding photos that aren't public"
 //	@Produce		json
 //	@Success		200	{object}	helpers.PhotosResponse
+//	@Success		304
 //	@Failure		400	{object}	helpers.ErrorResponse
 //	@Failure		404	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
@@ -165,7 +256,22 @@ func (h *PhotoHandler) GetMine(ctx *gin.Context) {
 func (h *PhotoHandler) GetByOwner(ctx *gin.Context) {
 	username := ctx.Param("username")
 
-	photos, err := h.c.GetByOwner(ctx, username)
+	var query dtos.PhotoListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.c.GetByOwner(ctx, username, query)
 	if err != nil {
 		var errController helpers.ResponseError
 		if errors.As(err, &errController) {
@@ -180,9 +286,57 @@ func (h *PhotoHandler) GetByOwner(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"photos": photos,
-	})
+	if notModified(ctx, photos.ETag, !photos.Public, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
+}
+
+// GetPhoto godoc
+//
+//	@Summary		get photo
+//	@Description	get details of a photo, visibility is only shown to the owner. photos you can't see on your own can be viewed with a share link
+//	@Tags			Photos
+//	@Param			id					path	string	true	"photo ID"
+//	@Param			share			query	string	false	"share link token, the X-Share-Token header can be used instead"
+//	@Param			X-Share-Password	header	string	false	"password of the share link"
+//	@Produce		json
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
+//	@Param			If-Modified-Since	header	string	false	"Last-Modified of a previous response, needed along with If-None-Match for photos that aren't public"
+//	@Success		200	{object}	dtos.PhotoDetailResponse
+//	@Success		304
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		410	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id} [get]
+//	@Security		Bearer
+func (h *PhotoHandler) GetByID(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	photo, err := h.c.GetByID(ctx, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	if notModified(ctx, photo.ETag, !photo.Public, photo.UpdatedAt) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photo)
 }
 
 // UpdatePhoto godoc
@@ -191,13 +345,15 @@ func (h *PhotoHandler) GetByOwner(ctx *gin.Context) {
 //	@Description	update data of a photo by given ID
 //	@Tags			Photos
 //	@Accept			json
-//	@Param			id		path	string					true	"photo ID"
-//	@Param			Body	body	dtos.UpdatePhotoRequest	true	"data required to update a photo"
+//	@Param			id			path	string					true	"photo ID"
+//	@Param			If-Match	header	string					false	"ETag of the photo the update is based on, the update fails if the photo has changed since"
+//	@Param			Body		body	dtos.UpdatePhotoRequest	true	"data required to update a photo"
 //	@Produce		json
 //	@Success		204
 //	@Failure		400	{object}	helpers.ErrorResponse
 //	@Failure		401	{object}	helpers.ErrorResponse
 //	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		412	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
 //	@Router			/photos/{id} [put]
 //	@Security		Bearer
@@ -225,7 +381,7 @@ func (h *PhotoHandler) Update(ctx *gin.Context) {
 		return
 	}
 
-	err := h.c.Update(ctx, data, photoID)
+	err := h.c.Update(ctx, data, photoID, ctx.GetHeader("If-Match"))
 	if err != nil {
 		var errController helpers.ResponseError
 		if errors.As(err, &errController) {
@@ -246,7 +402,7 @@ func (h *PhotoHandler) Update(ctx *gin.Context) {
 // Delete Photo godoc
 //
 //	@Summary		delete photo
-//	@Description	delete a photo by given ID
+//	@Description	move a photo to the trash, it's deleted for good once the trash retention period is over
 //	@Tags			Photos
 //	@Param			id	path	string	true	"photo ID"
 //	@Produce		json
@@ -277,3 +433,545 @@ func (h *PhotoHandler) Delete(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// GetPhotoMetadata godoc
+//
+//	@Summary		get metadata of a photo
+//	@Description	get camera, exposure, capture time and location data extracted from a photo. photos you can't see on your own can be viewed with a share link
+//	@Tags			Photos
+//	@Param			id					path	string	true	"photo ID"
+//	@Param			share				query	string	false	"share link token, the X-Share-Token header can be used instead"
+//	@Param			X-Share-Password	header	string	false	"password of the share link"
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
+//	@Produce		json
+//	@Success		200	{object}	dtos.PhotoMetadataResponse
+//	@Success		304
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		410	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/metadata [get]
+//	@Security		Bearer
+func (h *PhotoHandler) GetMetadata(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	metadata, err := h.c.GetMetadata(ctx, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	if notModified(ctx, metadata.ETag, false, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metadata)
+}
+
+// GetOriginalPhoto godoc
+//
+//	@Summary		download original photo
+//	@Description	download the untouched file of a photo (including all of its metadata), only available to the owner
+//	@Tags			Photos
+//	@Param			id	path	string	true	"photo ID"
+//	@Produce		octet-stream
+//	@Success		200
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/original [get]
+//	@Security		Bearer
+func (h *PhotoHandler) GetOriginal(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	file, obj, err := h.c.GetOriginal(ctx, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+	defer file.Close()
+
+	ctx.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s%s"`, photoID, path.Ext(obj.Key)),
+		"Cache-Control":       helpers.CacheNoStore,
+	})
+}
+
+// ServePhotoFile streams a file of a photo, addressed by the photo ID, from the configured storage backend.
+// conditional requests are answered with 304 Not Modified.
+func (h *PhotoHandler) ServePhotoFile(ctx *gin.Context) {
+	var version int
+	if ctx.Param("version") != "" {
+		v, err := strconv.Atoi(ctx.Param("version"))
+		if err != nil || v < 1 {
+			ctx.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		version = v
+	}
+
+	var query dtos.PhotoFileQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	file, info, err := h.c.GetPhotoFile(ctx, ctx.Param("photo_id"), ctx.Param("variant"), version, query)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatus(errController.Code())
+			return
+		}
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	cacheControl := helpers.CacheNoStore
+	if info.Public && info.Immutable {
+		cacheControl = helpers.CacheImmutable
+	} else if info.Public {
+		cacheControl = helpers.CacheRevalidate
+	}
+
+	ctx.Header("ETag", info.ETag)
+	ctx.Header("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	ctx.Header("Cache-Control", cacheControl)
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	if helpers.NotModified(ctx.Request, info.ETag, info.ModTime) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, file, nil)
+}
+
+// RedirectFile redirects a request for a file at its old /photos/<userID>/... path to its /files/<photoID> URL.
+func (h *PhotoHandler) RedirectFile(ctx *gin.Context) {
+	url, err := h.c.ResolveLegacyPath(ctx, ctx.Param("filepath"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatus(errController.Code())
+			return
+		}
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// signed URLs expire, so the redirect to a freshly signed one can't be cached for good.
+	code := http.StatusMovedPermanently
+	if ctx.GetBool("signed_url") {
+		code = http.StatusFound
+	}
+	ctx.Redirect(code, url)
+}
+
+// SearchPhotos godoc
+//
+//	@Summary		search photos
+//	@Description	full-text search over titles, captions and tags of public photos, best match first. current user's private photos are included as well
+//	@Tags			Photos
+//	@Param			query	query	dtos.PhotoSearchQuery	true	"search terms and pagination"
+//	@Param			If-None-Match		header	string	false	"ETag of a previous response"
+//	@Param			If-Modified-Since	header	string	false	"Last-Modified of a previous response, needed along with If-None-Match for pages holding photos that aren't public"
+//	@Produce		json
+//	@Success		200	{object}	helpers.SearchResponse
+//	@Success		304
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/search [get]
+//	@Security		Bearer
+func (h *PhotoHandler) Search(ctx *gin.Context) {
+	var query dtos.PhotoSearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.c.Search(ctx, query)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	if notModified(ctx, photos.ETag, !photos.Public, time.Time{}) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
+}
+
+// ReplacePhotoFile godoc
+//
+//	@Summary		replace photo file
+//	@Description	replace the image of a photo while keeping its ID, the previous file is kept as a version
+//	@Tags			Photos
+//	@Param			id		path		string					true	"photo ID"
+//	@Param			form	formData	dtos.ReplacePhotoRequest	true	"upload settings"
+//	@Param			photo	formData	file					true	"the picture file"
+//	@Produce		json
+//	@Success		200	{object}	dtos.ReplacePhotoResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		403	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		413	{object}	helpers.ErrorResponse
+//	@Failure		415	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/file [put]
+//	@Security		Bearer
+func (h *PhotoHandler) ReplaceFile(ctx *gin.Context) {
+	var data dtos.ReplacePhotoRequest
+	photoID := ctx.Param("id")
+	h.limitUpload(ctx)
+	if err := ctx.ShouldBind(&data); err != nil {
+		var errMaxBytes *http.MaxBytesError
+		if errors.As(err, &errMaxBytes) {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("photo must not be larger than %d bytes", h.maxBytes),
+			})
+			return
+		}
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if data.Photo == nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "photo can't be empty",
+		})
+		return
+	}
+
+	resp, err := h.c.ReplaceFile(ctx, data, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetPhotoVersions godoc
+//
+//	@Summary		get photo versions
+//	@Description	get the current and every previous file of a photo, newest first
+//	@Tags			Photos
+//	@Param			id	path	string	true	"photo ID"
+//	@Produce		json
+//	@Success		200	{object}	helpers.PhotoVersionsResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/versions [get]
+//	@Security		Bearer
+func (h *PhotoHandler) GetVersions(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	versions, err := h.c.GetVersions(ctx, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// RestorePhotoVersion godoc
+//
+//	@Summary		restore photo version
+//	@Description	make a previous file the current one, the replaced file is kept as a version
+//	@Tags			Photos
+//	@Param			id		path	string	true	"photo ID"
+//	@Param			version	path	int		true	"version number"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/versions/{version}/restore [post]
+//	@Security		Bearer
+func (h *PhotoHandler) RestoreVersion(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "version must be a number",
+		})
+		return
+	}
+
+	err = h.c.RestoreVersion(ctx, photoID, version)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeletePhotoVersion godoc
+//
+//	@Summary		delete photo version
+//	@Description	delete a previous file of a photo
+//	@Tags			Photos
+//	@Param			id		path	string	true	"photo ID"
+//	@Param			version	path	int		true	"version number"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/versions/{version} [delete]
+//	@Security		Bearer
+func (h *PhotoHandler) DeleteVersion(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "version must be a number",
+		})
+		return
+	}
+
+	err = h.c.DeleteVersion(ctx, photoID, version)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PurgePhotoVersions godoc
+//
+//	@Summary		purge photo versions
+//	@Description	delete previous files of a photo, except the most recent ones when keep is set
+//	@Tags			Photos
+//	@Param			id		path	string						true	"photo ID"
+//	@Param			query	query	dtos.PurgePhotoVersionsQuery	false	"versions to keep"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/versions [delete]
+//	@Security		Bearer
+func (h *PhotoHandler) PurgeVersions(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	var query dtos.PurgePhotoVersionsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := h.c.PurgeVersions(ctx, photoID, query)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetTrash godoc
+//
+//	@Summary		get trash
+//	@Description	get photos of current user that are in the trash, most recently deleted first
+//	@Tags			Photos
+//	@Produce		json
+//	@Success		200	{object}	helpers.TrashResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/trash [get]
+//	@Security		Bearer
+func (h *PhotoHandler) GetTrash(ctx *gin.Context) {
+	photos, err := h.c.GetTrash(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
+}
+
+// RestorePhoto godoc
+//
+//	@Summary		restore photo
+//	@Description	move a photo out of the trash
+//	@Tags			Photos
+//	@Param			id	path	string	true	"photo ID"
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/{id}/restore [post]
+//	@Security		Bearer
+func (h *PhotoHandler) Restore(ctx *gin.Context) {
+	photoID := ctx.Param("id")
+
+	err := h.c.Restore(ctx, photoID)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// EmptyTrash godoc
+//
+//	@Summary		empty trash
+//	@Description	delete for good every photo of current user that is in the trash
+//	@Tags			Photos
+//	@Produce		json
+//	@Success		204
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/photos/trash [delete]
+//	@Security		Bearer
+func (h *PhotoHandler) EmptyTrash(ctx *gin.Context) {
+	err := h.c.EmptyTrash(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
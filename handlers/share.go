@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"photo-app/controllers"
+	"photo-app/dtos"
+	"photo-app/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ShareHandler struct {
+	c controllers.ShareController
+}
+
+func NewShareHandler(c controllers.ShareController) *ShareHandler {
+	return &ShareHandler{c}
+}
+
+// CreateShareLink godoc
+//
+//	@Summary		create share link
+//	@Description	create a link giving access to one of your photos, or to one of your albums and its photos, whatever their visibility. the token is only returned once
+//	@Tags			Shares
+//	@Param			Body	body	dtos.CreateShareLinkRequest	true	"what to share and how"
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	dtos.CreateShareLinkResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		422	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/shares [post]
+//	@Security		Bearer
+func (h *ShareHandler) Create(ctx *gin.Context) {
+	var data dtos.CreateShareLinkRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	resp, err := h.c.Create(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// GetMyShareLinks godoc
+//
+//	@Summary		get all share links of current user
+//	@Description	get all share links created by current user, revoked and expired ones included
+//	@Tags			Shares
+//	@Produce		json
+//	@Success		200	{object}	helpers.ShareLinksResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/shares/my [get]
+//	@Security		Bearer
+func (h *ShareHandler) GetMine(ctx *gin.Context) {
+	links, err := h.c.GetMine(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink godoc
+//
+//	@Summary		revoke share link
+//	@Description	revoke a share link for good, its uses are kept
+//	@Tags			Shares
+//	@Param			id	path	string	true	"share link ID"
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/shares/{id} [delete]
+//	@Security		Bearer
+func (h *ShareHandler) Revoke(ctx *gin.Context) {
+	err := h.c.Revoke(ctx, ctx.Param("id"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetShareLinkUses godoc
+//
+//	@Summary		get share link uses
+//	@Description	get every recorded use of a share link, most recent first
+//	@Tags			Shares
+//	@Param			id	path	string	true	"share link ID"
+//	@Produce		json
+//	@Success		200	{object}	helpers.ShareLinkUsesResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/shares/{id}/uses [get]
+//	@Security		Bearer
+func (h *ShareHandler) GetUses(ctx *gin.Context) {
+	uses, err := h.c.GetUses(ctx, ctx.Param("id"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, uses)
+}
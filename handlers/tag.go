@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"photo-app/controllers"
+	"photo-app/dtos"
+	"photo-app/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type TagHandler struct {
+	c controllers.TagController
+}
+
+func NewTagHandler(c controllers.TagController) *TagHandler {
+	return &TagHandler{c}
+}
+
+// GetAllTags godoc
+//
+//	@Summary		get tags
+//	@Description	get the most used tags along with the number of public photos using them
+//	@Tags			Tags
+//	@Param			query	query	dtos.TagListQuery	false	"number of tags to return"
+//	@Produce		json
+//	@Success		200	{object}	helpers.TagsResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/tags [get]
+func (h *TagHandler) GetAll(ctx *gin.Context) {
+	var query dtos.TagListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tags, err := h.c.GetAll(ctx, query)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// GetPhotosByTag godoc
+//
+//	@Summary		get photos by tag
+//	@Description	get public photos with the given tag, current user's private photos are included as well
+//	@Tags			Tags
+//	@Param			tag		path	string				true	"tag"
+//	@Param			query	query	dtos.PhotoListQuery	false	"pagination, sorting and filtering"
+//	@Produce		json
+//	@Success		200	{object}	helpers.PhotosResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/tags/{tag}/photos [get]
+//	@Security		Bearer
+func (h *TagHandler) GetPhotos(ctx *gin.Context) {
+	tag := ctx.Param("tag")
+
+	var query dtos.PhotoListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.c.GetPhotos(ctx, tag, query)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, photos)
+}
@@ -30,7 +30,6 @@ func NewUserHandler(uc controllers.UserController) *UserHandler {
 //	@Produce		json
 //	@Success		201	{object}	dtos.RegisterResponse
 //	@Failure		400	{object}	helpers.ErrorResponse
-//	@Failure		429	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
 //	@Router			/users/register [post]
 func (h *UserHandler) Register(ctx *gin.Context) {
@@ -78,7 +77,7 @@ func (h *UserHandler) Register(ctx *gin.Context) {
 // UserLogin godoc
 //
 //	@Summary		user login
-//	@Description	login user. returns JWT
+//	@Description	login user on a new session. returns a short-lived JWT and a refresh token
 //	@Tags			Users
 //	@Param			Body	body	dtos.UserLogin	true	"data required to login"
 //	@Accept			json
@@ -111,6 +110,8 @@ func (h *UserHandler) Login(ctx *gin.Context) {
 		})
 		return
 	}
+	data.IP = ctx.ClientIP()
+	data.UserAgent = ctx.Request.UserAgent()
 
 	resp, err := h.c.Login(ctx, data)
 	if err != nil {
@@ -130,17 +131,354 @@ func (h *UserHandler) Login(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// UserRefresh godoc
+//
+//	@Summary		refresh tokens
+//	@Description	exchange a refresh token for a new JWT and a new refresh token. a refresh token can only be used once, using it again signs its session out
+//	@Tags			Users
+//	@Param			Body	body	dtos.RefreshRequest	true	"refresh token of the session"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dtos.LoginResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/refresh [post]
+func (h *UserHandler) Refresh(ctx *gin.Context) {
+	var data dtos.RefreshRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	resp, err := h.c.Refresh(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UserLogout godoc
+//
+//	@Summary		user logout
+//	@Description	sign out the session of a refresh token, its JWTs are rejected from then on
+//	@Tags			Users
+//	@Param			Body	body	dtos.LogoutRequest	true	"refresh token of the session"
+//	@Accept			json
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/logout [post]
+func (h *UserHandler) Logout(ctx *gin.Context) {
+	var data dtos.LogoutRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.Logout(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		verify email
+//	@Description	verify the email of a user with the token of their verification email, either from the link (GET) or sent by a client (POST). a token can only be used once
+//	@Tags			Users
+//	@Param			token	query	string					false	"verification token, for GET"
+//	@Param			Body	body	dtos.VerifyEmailRequest	false	"verification token, for POST"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dtos.VerifyEmailResponse
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/verify [get]
+//	@Router			/users/verify [post]
+func (h *UserHandler) VerifyEmail(ctx *gin.Context) {
+	var data dtos.VerifyEmailRequest
+	// the token is read from the query on GET and from the JSON body on POST.
+	if err := ctx.ShouldBind(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	res, err := h.c.VerifyEmail(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ResendVerification godoc
+//
+//	@Summary		resend verification email
+//	@Description	send the verification email of the current user again, it can only be asked for once in a while
+//	@Tags			Users
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		429	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/verify/resend [post]
+//	@Security		Bearer
+func (h *UserHandler) ResendVerification(ctx *gin.Context) {
+	err := h.c.ResendVerification(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		forgot password
+//	@Description	email a password reset token to the user with the given email. it's accepted whether the email is registered or not
+//	@Tags			Users
+//	@Param			Body	body	dtos.ForgotPasswordRequest	true	"email of the account"
+//	@Accept			json
+//	@Produce		json
+//	@Success		202
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/password/forgot [post]
+func (h *UserHandler) ForgotPassword(ctx *gin.Context) {
+	var data dtos.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.ForgotPassword(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+//
+//	@Summary		reset password
+//	@Description	set a new password with the token of a password reset email. a token can only be used once, every session of the user is signed out
+//	@Tags			Users
+//	@Param			Body	body	dtos.ResetPasswordRequest	true	"token and new password"
+//	@Accept			json
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/password/reset [post]
+func (h *UserHandler) ResetPassword(ctx *gin.Context) {
+	var data dtos.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.ResetPassword(ctx, data)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// UserGetMe godoc
+//
+//	@Summary		current user
+//	@Description	get data of the current user, the ETag header can be sent back as If-Match when updating it
+//	@Tags			Users
+//	@Produce		json
+//	@Success		200	{object}	dtos.UserDetailResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/me [get]
+//	@Security		Bearer
+func (h *UserHandler) GetMe(ctx *gin.Context) {
+	user, err := h.c.GetMe(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Header("ETag", user.ETag)
+	ctx.Header("Cache-Control", helpers.CacheNoStore)
+	ctx.JSON(http.StatusOK, user)
+}
+
 // UserUpdate godoc
 //
 //	@Summary		user update
 //	@Description	update user data
 //	@Tags			Users
-//	@Param			Body	body	dtos.UserUpdateRequest	true	"data required to update user data"
+//	@Param			If-Match	header	string					false	"ETag of the user the update is based on, the update fails if the user has changed since"
+//	@Param			Body		body	dtos.UserUpdateRequest	true	"data required to update user data"
 //	@Accept			json
 //	@Produce		json
 //	@Success		204
 //	@Failure		400	{object}	helpers.ErrorResponse
 //	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		412	{object}	helpers.ErrorResponse
 //	@Failure		429	{object}	helpers.ErrorResponse
 //	@Failure		500	{object}	helpers.ErrorResponse
 //	@Router			/users/me [put]
@@ -168,7 +506,62 @@ func (h *UserHandler) Update(ctx *gin.Context) {
 		return
 	}
 
-	err := h.c.Update(ctx, data)
+	err := h.c.Update(ctx, data, ctx.GetHeader("If-Match"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// UserUpdateSettings godoc
+//
+//	@Summary		update user settings
+//	@Description	update default upload settings of current user
+//	@Tags			Users
+//	@Param			Body	body	dtos.UserSettingsRequest	true	"settings to update"
+//	@Accept			json
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/me/settings [put]
+//	@Security		Bearer
+func (h *UserHandler) UpdateSettings(ctx *gin.Context) {
+	var data dtos.UserSettingsRequest
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		var errValidation validator.ValidationErrors
+		if errors.As(err, &errValidation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"errors": helpers.GetValidationError(errValidation),
+			})
+			return
+		}
+		var errJSON *json.SyntaxError
+		if errors.As(err, &errJSON) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "unable to parse JSON/invalid JSON format",
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	err := h.c.UpdateSettings(ctx, data)
 	if err != nil {
 		var errController helpers.ResponseError
 		if errors.As(err, &errController) {
@@ -216,3 +609,132 @@ func (h *UserHandler) Delete(ctx *gin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// GetSessions godoc
+//
+//	@Summary		list sessions
+//	@Description	list the signed in devices of the current user, the most recently used first
+//	@Tags			Users
+//	@Produce		json
+//	@Success		200	{object}	helpers.SessionsResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/me/sessions [get]
+//	@Security		Bearer
+func (h *UserHandler) GetSessions(ctx *gin.Context) {
+	sessions, err := h.c.GetSessions(ctx)
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// DeleteSession godoc
+//
+//	@Summary		sign out a session
+//	@Description	sign out one of the sessions of the current user, its tokens are rejected from then on
+//	@Tags			Users
+//	@Param			id	path	string	true	"session ID"
+//	@Produce		json
+//	@Success		204
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/me/sessions/{id} [delete]
+//	@Security		Bearer
+func (h *UserHandler) DeleteSession(ctx *gin.Context) {
+	err := h.c.DeleteSession(ctx, ctx.Param("id"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// FollowUser godoc
+//
+//	@Summary		follow a user
+//	@Description	follow a user to see the photos they share with their followers
+//	@Tags			Users
+//	@Param			username	path	string	true	"username of the user to follow"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		409	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/{username}/follow [post]
+//	@Security		Bearer
+func (h *UserHandler) Follow(ctx *gin.Context) {
+	err := h.c.Follow(ctx, ctx.Param("username"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// UnfollowUser godoc
+//
+//	@Summary		unfollow a user
+//	@Description	stop following a user
+//	@Tags			Users
+//	@Param			username	path	string	true	"username of the user to unfollow"
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	helpers.ErrorResponse
+//	@Failure		401	{object}	helpers.ErrorResponse
+//	@Failure		404	{object}	helpers.ErrorResponse
+//	@Failure		500	{object}	helpers.ErrorResponse
+//	@Router			/users/{username}/follow [delete]
+//	@Security		Bearer
+func (h *UserHandler) Unfollow(ctx *gin.Context) {
+	err := h.c.Unfollow(ctx, ctx.Param("username"))
+	if err != nil {
+		var errController helpers.ResponseError
+		if errors.As(err, &errController) {
+			ctx.AbortWithStatusJSON(errController.Code(), gin.H{
+				"error": errController.Error(),
+			})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": helpers.ErrInternal,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+	"photo-app/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys access tokens are verified with, so other services can verify them on their own.
+// keys change on rotation, so the set is only cached for a few minutes.
+func JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, helpers.JWKS())
+}
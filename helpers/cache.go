@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cache-Control values of the served responses.
+const (
+	// CacheImmutable is sent with public files requested at a URL that always points to the same content.
+	CacheImmutable = "public, max-age=31536000, immutable"
+	// CacheRevalidate is sent with public files requested at a URL whose content can change, ex: after a replace.
+	CacheRevalidate = "public, no-cache"
+	// CachePrivate is sent with responses that depend on the current user, they must be revalidated before reuse.
+	CachePrivate = "private, no-cache"
+	// CacheNoStore is sent with responses that must never be kept, such as files of photos that aren't public.
+	CacheNoStore = "private, no-store"
+)
+
+// Checksum returns the hex SHA-256 of the content of a file.
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ETag returns a strong entity tag built from the hash of values, the same values always give the same tag.
+func ETag(values ...any) string {
+	h := sha256.New()
+	for _, v := range values {
+		fmt.Fprintf(h, "%v\n", v)
+	}
+
+	return `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// NotModified reports whether a GET or HEAD request can be answered with 304 Not Modified. If-None-Match takes
+// precedence over If-Modified-Since, an empty etag or a zero modTime never match.
+func NotModified(r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag, true)
+	}
+
+	return notModifiedSince(r, modTime)
+}
+
+// NotModifiedSigned is NotModified for responses holding signed URLs, modTime being the time they were signed. the
+// ETag doesn't tell them apart, so both If-None-Match and If-Modified-Since have to match, a copy with URLs signed
+// before modTime is never reused.
+func NotModifiedSigned(r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	inm := r.Header.Get("If-None-Match")
+	return inm != "" && matchETag(inm, etag, true) && notModifiedSince(r, modTime)
+}
+
+// notModifiedSince reports whether the If-Modified-Since header of a request is set and not before modTime.
+func notModifiedSince(r *http.Request, modTime time.Time) bool {
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// HTTP dates have a one second precision.
+	return !modTime.Truncate(time.Second).After(t)
+}
+
+// IfMatch reports whether the If-Match header of an update matches the current etag of the resource, an empty
+// header always matches. weak tags never match, as they can't tell the exact version the client has.
+func IfMatch(header, etag string) bool {
+	if header == "" {
+		return true
+	}
+
+	return matchETag(header, etag, false)
+}
+
+// matchETag reports whether etag is in the comma separated list of tags of a conditional header.
+func matchETag(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
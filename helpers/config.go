@@ -1,7 +1,9 @@
 package helpers
 
 import (
+	"errors"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,8 +12,11 @@ type (
 	Config struct {
 		App       App
 		DB        DB
+		Storage   Storage
+		Photo     Photo
+		Auth      Auth
+		Mail      Mail
 		JWTSecret string `mapstructure:"JWT_SECRET"`
-		PhotoDir  string `mapstructure:"PHOTO_DIR"`
 	}
 	App struct {
 		Port uint `mapstructure:"APP_PORT"`
@@ -23,13 +28,80 @@ type (
 		Host     string `mapstructure:"DB_HOST"`
 		Port     uint   `mapstructure:"DB_PORT"`
 	}
+	Storage struct {
+		Backend     string `mapstructure:"STORAGE_BACKEND"`
+		PhotoDir    string `mapstructure:"PHOTO_DIR"`
+		S3Bucket    string `mapstructure:"S3_BUCKET"`
+		S3Prefix    string `mapstructure:"S3_PREFIX"`
+		S3Endpoint  string `mapstructure:"S3_ENDPOINT"`
+		S3Region    string `mapstructure:"S3_REGION"`
+		S3PathStyle bool   `mapstructure:"S3_PATH_STYLE"`
+		S3AccessKey string `mapstructure:"S3_ACCESS_KEY"`
+		S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
+		// DeletionInterval is how often pending file deletions are retried.
+		DeletionInterval  time.Duration `mapstructure:"STORAGE_DELETION_INTERVAL"`
+		ReconcileInterval time.Duration `mapstructure:"STORAGE_RECONCILE_INTERVAL"`
+	}
+	Photo struct {
+		RawVariants  string         `mapstructure:"PHOTO_VARIANTS"`
+		Variants     []ImageVariant `mapstructure:"-"`
+		MaxBytes     int64          `mapstructure:"PHOTO_MAX_BYTES"`
+		MaxDimension int            `mapstructure:"PHOTO_MAX_DIMENSION"`
+		// MaxPixels caps the width times the height of a photo, since a photo within MaxDimension can still take
+		// gigabytes of memory once decoded.
+		MaxPixels int `mapstructure:"PHOTO_MAX_PIXELS"`
+		// TrashRetention is how long deleted photos are kept in the trash before being purged.
+		TrashRetention     time.Duration `mapstructure:"PHOTO_TRASH_RETENTION"`
+		TrashPurgeInterval time.Duration `mapstructure:"PHOTO_TRASH_PURGE_INTERVAL"`
+		// URLSigningKey signs the file URLs of photos that aren't public, they're valid for URLTTL.
+		URLSigningKey string        `mapstructure:"PHOTO_URL_SIGNING_KEY"`
+		URLTTL        time.Duration `mapstructure:"PHOTO_URL_TTL"`
+	}
+	Auth struct {
+		// AccessTokenTTL is how long an access token is valid, the refresh token gets a new one after that.
+		AccessTokenTTL time.Duration `mapstructure:"AUTH_ACCESS_TOKEN_TTL"`
+		// RefreshTokenTTL is how long a session can stay unused before it has to sign in again.
+		RefreshTokenTTL time.Duration `mapstructure:"AUTH_REFRESH_TOKEN_TTL"`
+		// JWTSigningKey is the PEM file of the Ed25519 or RSA private key new tokens are signed with. when empty,
+		// tokens are signed with JWT_SECRET using HS256.
+		JWTSigningKey string `mapstructure:"AUTH_JWT_SIGNING_KEY"`
+		// JWTVerificationKeys are comma separated PEM files of previous keys, private or public. tokens they signed
+		// are still accepted, so they can be kept until those tokens have expired after a rotation.
+		JWTVerificationKeys string `mapstructure:"AUTH_JWT_VERIFICATION_KEYS"`
+		// EmailTokenKey signs the tokens emailed to users, they're valid for EmailTokenTTL.
+		EmailTokenKey string        `mapstructure:"AUTH_EMAIL_TOKEN_KEY"`
+		EmailTokenTTL time.Duration `mapstructure:"AUTH_EMAIL_TOKEN_TTL"`
+		// VerificationResendInterval is how long a user has to wait before the verification email is sent again.
+		VerificationResendInterval time.Duration `mapstructure:"AUTH_VERIFICATION_RESEND_INTERVAL"`
+		// PasswordResetTTL is how long the token of a password reset email can be used.
+		PasswordResetTTL time.Duration `mapstructure:"AUTH_PASSWORD_RESET_TTL"`
+		// PasswordResetInterval is how long a user has to wait before another password reset email is sent.
+		PasswordResetInterval time.Duration `mapstructure:"AUTH_PASSWORD_RESET_INTERVAL"`
+	}
+	Mail struct {
+		// Backend is where emails are sent: smtp, file (written to Dir as .eml files) or log (never sent, only their
+		// recipient and subject are logged). it has no default, so emails can't be dropped silently.
+		Backend      string `mapstructure:"MAIL_BACKEND"`
+		From         string `mapstructure:"MAIL_FROM"`
+		SMTPHost     string `mapstructure:"MAIL_SMTP_HOST"`
+		SMTPPort     uint   `mapstructure:"MAIL_SMTP_PORT"`
+		SMTPUsername string `mapstructure:"MAIL_SMTP_USERNAME"`
+		SMTPPassword string `mapstructure:"MAIL_SMTP_PASSWORD"`
+		Dir          string `mapstructure:"MAIL_DIR"`
+		// LinkBaseURL is the URL the links in emails start with (ex: https://photos.example.org).
+		LinkBaseURL string `mapstructure:"MAIL_LINK_BASE_URL"`
+	}
 )
 
 func LoadConfig(configFile string) (Config, error) {
 	var (
-		app  App
-		db   DB
-		conf Config
+		app     App
+		db      DB
+		storage Storage
+		photo   Photo
+		auth    Auth
+		mail    Mail
+		conf    Config
 	)
 	_, err := os.Stat(configFile)
 	if err != nil {
@@ -39,6 +111,24 @@ func LoadConfig(configFile string) (Config, error) {
 	v := viper.New()
 	v.AutomaticEnv()
 	v.SetConfigFile(configFile)
+	v.SetDefault("PHOTO_MAX_BYTES", 20<<20)
+	v.SetDefault("PHOTO_MAX_DIMENSION", 10000)
+	v.SetDefault("PHOTO_MAX_PIXELS", 50_000_000)
+	v.SetDefault("PHOTO_TRASH_RETENTION", "720h")
+	v.SetDefault("PHOTO_TRASH_PURGE_INTERVAL", "1h")
+	v.SetDefault("PHOTO_URL_TTL", "15m")
+	v.SetDefault("STORAGE_DELETION_INTERVAL", "1m")
+	v.SetDefault("STORAGE_RECONCILE_INTERVAL", "24h")
+	v.SetDefault("AUTH_ACCESS_TOKEN_TTL", "15m")
+	v.SetDefault("AUTH_REFRESH_TOKEN_TTL", "720h")
+	v.SetDefault("AUTH_EMAIL_TOKEN_TTL", "48h")
+	v.SetDefault("AUTH_VERIFICATION_RESEND_INTERVAL", "1m")
+	v.SetDefault("AUTH_PASSWORD_RESET_TTL", "1h")
+	v.SetDefault("AUTH_PASSWORD_RESET_INTERVAL", "1m")
+	v.SetDefault("MAIL_FROM", "Photo App <no-reply@localhost>")
+	v.SetDefault("MAIL_SMTP_PORT", 25)
+	v.SetDefault("MAIL_DIR", "mails")
+	v.SetDefault("MAIL_LINK_BASE_URL", "http://localhost:8080")
 
 	if err := v.ReadInConfig(); err != nil {
 		return conf, err
@@ -52,15 +142,65 @@ func LoadConfig(configFile string) (Config, error) {
 		return conf, err
 	}
 
+	if err := v.Unmarshal(&storage); err != nil {
+		return conf, err
+	}
+
+	if err := v.Unmarshal(&photo); err != nil {
+		return conf, err
+	}
+
+	photo.Variants, err = ParseImageVariants(photo.RawVariants)
+	if err != nil {
+		return conf, err
+	}
+
+	if photo.URLSigningKey == "" {
+		return conf, errors.New("PHOTO_URL_SIGNING_KEY is required")
+	}
+	if photo.URLTTL < time.Minute {
+		return conf, errors.New("PHOTO_URL_TTL must be at least 1m")
+	}
+
+	if err := v.Unmarshal(&auth); err != nil {
+		return conf, err
+	}
+	if auth.AccessTokenTTL <= 0 || auth.RefreshTokenTTL <= auth.AccessTokenTTL {
+		return conf, errors.New("AUTH_REFRESH_TOKEN_TTL must be longer than AUTH_ACCESS_TOKEN_TTL")
+	}
+	if auth.EmailTokenKey == "" {
+		return conf, errors.New("AUTH_EMAIL_TOKEN_KEY is required")
+	}
+	if auth.EmailTokenTTL < time.Minute {
+		return conf, errors.New("AUTH_EMAIL_TOKEN_TTL must be at least 1m")
+	}
+	if auth.PasswordResetTTL < time.Minute {
+		return conf, errors.New("AUTH_PASSWORD_RESET_TTL must be at least 1m")
+	}
+
+	if err := v.Unmarshal(&mail); err != nil {
+		return conf, err
+	}
+	if mail.Backend == "" {
+		return conf, errors.New("MAIL_BACKEND is required")
+	}
+
 	if err := v.Unmarshal(&conf); err != nil {
 		return conf, err
 	}
 
 	conf.DB = db
 	conf.App = app
+	conf.Storage = storage
+	conf.Photo = photo
+	conf.Auth = auth
+	conf.Mail = mail
 
-	os.Setenv("JWT_SECRET", conf.JWTSecret)
-	os.Setenv("PHOTO_DIR", conf.PhotoDir)
+	keys, err := LoadJWTKeys(auth, conf.JWTSecret)
+	if err != nil {
+		return conf, err
+	}
+	UseJWTKeys(keys)
 
 	return conf, nil
 }
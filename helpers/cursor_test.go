@@ -0,0 +1,57 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 45, 123456789, time.UTC)
+
+	t.Run("round trip", func(t *testing.T) {
+		cursors := []Cursor{
+			{CreatedAt: createdAt, ID: "0b6f3a3c-5d0e-4f43-9d6b-2a1c5e7f8a90"},
+			{CreatedAt: createdAt, ID: "p1", Prev: true},
+			{CreatedAt: createdAt.In(time.FixedZone("WIB", 7*60*60)), ID: "p2"},
+		}
+
+		for _, c := range cursors {
+			s := EncodeCursor(c)
+			got, err := DecodeCursor(s)
+			if err != nil {
+				t.Fatalf("%q: %v", s, err)
+			}
+			if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID || got.Prev != c.Prev {
+				t.Errorf("got %+v, want %+v", got, c)
+			}
+		}
+	})
+
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	malformed := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T12:30:45Z","i":"p1"}`))},
+		{"not json", encode("p1")},
+		{"wrong types", encode(`{"t":1714566645,"i":1}`)},
+		{"missing id", encode(`{"t":"2024-05-01T12:30:45Z"}`)},
+		{"missing time", encode(`{"i":"p1"}`)},
+		{"invalid time", encode(`{"t":"yesterday","i":"p1"}`)},
+	}
+
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %+v, %v, want ErrInvalidCursor", c, err)
+			}
+		})
+	}
+}
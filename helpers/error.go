@@ -3,6 +3,8 @@ package helpers
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -10,6 +12,10 @@ import (
 var (
 	ErrInternal   = errors.New("it's our fault, not yours")
 	ErrNotAllowed = errors.New("you're not allowed to perform this action")
+	// ErrPreconditionFailed is returned when the If-Match header of an update doesn't match the current version.
+	ErrPreconditionFailed = errors.New("it has been changed since you fetched it, fetch it again and retry")
+	// ErrEmailNotVerified is returned when a user who hasn't verified their email yet tries to upload.
+	ErrEmailNotVerified = errors.New("please verify your email before uploading photos")
 )
 
 type ResponseError struct {
@@ -55,8 +61,24 @@ func GetValidationError(errValidation validator.ValidationErrors) []string {
 			vErr.Message = "must be a valid e-mail (ex: johndoe@mail.com)"
 		case "min":
 			vErr.Message = fmt.Sprintf("must be at least %s characters long", e.Param())
+			if e.Kind() != reflect.String {
+				vErr.Message = fmt.Sprintf("must be at least %s", e.Param())
+			}
+		case "max":
+			switch e.Kind() {
+			case reflect.String:
+				vErr.Message = fmt.Sprintf("must be at most %s characters long", e.Param())
+			case reflect.Slice, reflect.Map:
+				vErr.Message = fmt.Sprintf("must have at most %s items", e.Param())
+			default:
+				vErr.Message = fmt.Sprintf("must be at most %s", e.Param())
+			}
 		case "url":
 			vErr.Message = "must be a valid URL (ex: http://example.org)"
+		case "oneof":
+			vErr.Message = fmt.Sprintf("must be one of: %s", strings.ReplaceAll(e.Param(), " ", ", "))
+		case "unique":
+			vErr.Message = "must not contain duplicates"
 		default:
 			vErr.Message = e.Error()
 		}
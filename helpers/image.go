@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var variantNameRe = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+var (
+	ErrUnsupportedImage = errors.New("photo must be a JPEG, PNG, GIF or WebP image")
+	ErrInvalidImage     = errors.New("photo must be a valid image")
+)
+
+type ImageFormat struct {
+	Name        string
+	Ext         string
+	ContentType string
+}
+
+// imageFormats is the allowlist of accepted upload formats, identified by their magic bytes.
+var imageFormats = []struct {
+	ImageFormat
+	match func([]byte) bool
+}{
+	{ImageFormat{"jpeg", ".jpg", "image/jpeg"}, func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte{0xFF, 0xD8, 0xFF})
+	}},
+	{ImageFormat{"png", ".png", "image/png"}, func(b []byte) bool {
+		return bytes.HasPrefix(b, pngSignature)
+	}},
+	{ImageFormat{"gif", ".gif", "image/gif"}, func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte("GIF87a")) || bytes.HasPrefix(b, []byte("GIF89a"))
+	}},
+	{ImageFormat{"webp", ".webp", "image/webp"}, func(b []byte) bool {
+		return len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+	}},
+}
+
+// DecodeImage identifies an image by its content (never by the file name or the client provided content type),
+// checks its dimensions against maxDimension and its pixel count against maxPixels and fully decodes it, so truncated
+// or disguised files are rejected.
+func DecodeImage(data []byte, maxDimension, maxPixels int) (image.Image, ImageFormat, error) {
+	var format ImageFormat
+	for _, f := range imageFormats {
+		if f.match(data) {
+			format = f.ImageFormat
+			break
+		}
+	}
+	if format.Name == "" {
+		return nil, format, ErrUnsupportedImage
+	}
+
+	// check the dimensions from the header first, so we never allocate memory for huge images.
+	conf, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || name != format.Name {
+		return nil, format, ErrInvalidImage
+	}
+	if conf.Width <= 0 || conf.Height <= 0 {
+		return nil, format, ErrInvalidImage
+	}
+	if maxDimension > 0 && (conf.Width > maxDimension || conf.Height > maxDimension) {
+		return nil, format, fmt.Errorf("photo must not be larger than %dx%d pixels", maxDimension, maxDimension)
+	}
+	if maxPixels > 0 && int64(conf.Width)*int64(conf.Height) > int64(maxPixels) {
+		return nil, format, fmt.Errorf("photo must not have more than %d pixels", maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, ErrInvalidImage
+	}
+
+	return img, format, nil
+}
+
+type ImageVariant struct {
+	Name    string
+	MaxSize int
+}
+
+// ParseImageVariants parses variants in "name:size" format separated by comma (ex: "thumb:256,medium:1024").
+func ParseImageVariants(s string) ([]ImageVariant, error) {
+	var variants []ImageVariant
+
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		name, size, ok := strings.Cut(v, ":")
+		if !ok || !variantNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid image variant %q", v)
+		}
+
+		maxSize, err := strconv.Atoi(size)
+		if err != nil || maxSize <= 0 {
+			return nil, fmt.Errorf("invalid size for image variant %q", v)
+		}
+
+		variants = append(variants, ImageVariant{name, maxSize})
+	}
+
+	return variants, nil
+}
+
+// OrientImage applies an EXIF orientation (1 to 8) to the pixels of img, so it's displayed upright without the
+// tag. width and height are swapped for orientations 5 to 8, which rotate the image by 90 degrees.
+func OrientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		src = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	}
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// (sx, sy) is the source pixel displayed at (x, y).
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally.
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°.
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically.
+				sx, sy = x, h-1-y
+			case 5: // mirrored along the top-left to bottom-right diagonal.
+				sx, sy = y, x
+			case 6: // to be rotated 90° clockwise.
+				sx, sy = y, h-1-x
+			case 7: // mirrored along the top-right to bottom-left diagonal.
+				sx, sy = w-1-y, h-1-x
+			case 8: // to be rotated 90° counterclockwise.
+				sx, sy = w-1-y, x
+			}
+
+			si := src.PixOffset(src.Rect.Min.X+sx, src.Rect.Min.Y+sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
+
+// ResizeImage scales img down so that its longest side is at most maxSize pixels, keeping the aspect ratio.
+// images that are already small enough are returned as is.
+func ResizeImage(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+
+	if w >= h {
+		w, h = maxSize, max(1, h*maxSize/w)
+	} else {
+		w, h = max(1, w*maxSize/h), maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// EncodeImage encodes img as PNG when the source format may contain transparency, and as JPEG otherwise.
+// it returns the file extension and content type of the encoded image.
+func EncodeImage(w io.Writer, img image.Image, format string) (string, string, error) {
+	switch format {
+	case "png", "gif", "webp":
+		err := png.Encode(w, img)
+		return ".png", "image/png", err
+	default:
+		err := jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+		return ".jpg", "image/jpeg", err
+	}
+}
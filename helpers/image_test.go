@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+// pngHeader crafts a PNG declaring the given dimensions, with no pixel data after its header.
+func pngHeader(width, height uint32) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+
+	b := binary.BigEndian.AppendUint32(bytes.Clone(pngSignature), uint32(len(ihdr)))
+	b = append(b, "IHDR"...)
+	b = append(b, ihdr...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[len(pngSignature)+4:]))
+}
+
+func TestDecodeImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		maxDimension int
+		maxPixels    int
+		// err is the error expected, or a substring of its message when it isn't a sentinel.
+		err     error
+		message string
+	}{
+		{name: "valid", data: testPNG(t), maxDimension: 10000, maxPixels: 50_000_000},
+		{name: "too many pixels", data: pngHeader(8000, 8000), maxDimension: 10000, maxPixels: 50_000_000, message: "more than 50000000 pixels"},
+		{name: "no dimension limit", data: pngHeader(100000, 100000), maxPixels: 50_000_000, message: "more than 50000000 pixels"},
+		{name: "too wide", data: pngHeader(10001, 1), maxDimension: 10000, maxPixels: 50_000_000, message: "larger than 10000x10000"},
+		// a header within the limits is fully decoded, which fails without pixel data.
+		{name: "within limits", data: pngHeader(5000, 5000), maxDimension: 10000, maxPixels: 50_000_000, err: ErrInvalidImage},
+		{name: "no pixel limit", data: pngHeader(8000, 8000), maxDimension: 10000, err: ErrInvalidImage},
+		{name: "unsupported", data: []byte("BM not an accepted format"), err: ErrUnsupportedImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := DecodeImage(tt.data, tt.maxDimension, tt.maxPixels)
+			switch {
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("got %v, want %v", err, tt.err)
+				}
+			case tt.message != "":
+				if err == nil || !strings.Contains(err.Error(), tt.message) {
+					t.Errorf("got %v, want an error containing %q", err, tt.message)
+				}
+			case err != nil:
+				t.Errorf("got %v", err)
+			case img == nil || format.Name != "png":
+				t.Errorf("got %v, %+v", img, format)
+			}
+		})
+	}
+}
@@ -1,29 +1,228 @@
 package helpers
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type jwtClaims struct {
 	ID string `json:"id"`
+	// SessionID is the session the token was issued for, the token is rejected once the session is revoked.
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(id string) (string, error) {
+// JWTKeys are the keys access tokens are signed and verified with. with an asymmetric signing key, tokens carry
+// the kid of their key and are verified with the matching key, previous keys can be kept to verify the tokens
+// they signed during a rotation. without one, tokens are signed with the JWT secret using HS256.
+type JWTKeys struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+	// published lists the keys in the order they're published, the signing key first.
+	published []*jwtKey
+	secret    []byte
+}
+
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JWK is a public key as published in a JWK set, see RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwtKeys are the keys used by GenerateJWT and ParseJWT, they're set once on startup by UseJWTKeys.
+var jwtKeys JWTKeys
+
+// UseJWTKeys sets the keys access tokens are signed and verified with.
+func UseJWTKeys(keys JWTKeys) {
+	jwtKeys = keys
+}
+
+// LoadJWTKeys reads the PEM files of the signing key and of the verification keys of conf, Ed25519 and RSA keys
+// are supported. secret is only used when no signing key is configured.
+func LoadJWTKeys(conf Auth, secret string) (JWTKeys, error) {
+	keys := JWTKeys{keys: make(map[string]*jwtKey)}
+	if conf.JWTSigningKey == "" {
+		if secret == "" {
+			return keys, errors.New("either AUTH_JWT_SIGNING_KEY or JWT_SECRET is required")
+		}
+		keys.secret = []byte(secret)
+		return keys, nil
+	}
+
+	signing, err := readJWTKey(conf.JWTSigningKey)
+	if err != nil {
+		return keys, err
+	}
+	if signing.private == nil {
+		return keys, fmt.Errorf("%s: the signing key must be a private key", conf.JWTSigningKey)
+	}
+	keys.signing = signing
+	keys.keys[signing.kid] = signing
+	keys.published = append(keys.published, signing)
+
+	for _, file := range strings.Split(conf.JWTVerificationKeys, ",") {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+
+		key, err := readJWTKey(file)
+		if err != nil {
+			return keys, err
+		}
+		if _, ok := keys.keys[key.kid]; !ok {
+			keys.keys[key.kid] = key
+			keys.published = append(keys.published, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// readJWTKey reads a PEM encoded private or public key, its kid is its JWK thumbprint (RFC 7638).
+func readJWTKey(file string) (*jwtKey, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	key := &jwtKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		key.public = signer.Public()
+	} else {
+		key.public = parsed
+	}
+
+	switch public := key.public.(type) {
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("%s: RSA keys must be at least 2048 bits", file)
+		}
+		key.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("%s: only Ed25519 and RSA keys are supported", file)
+	}
+
+	key.kid = thumbprint(key.jwk())
+	return key, nil
+}
+
+func (k *jwtKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Alg: k.method.Alg(), Use: "sig"}
+	switch public := k.public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(public)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+
+	return jwk
+}
+
+// thumbprint returns the RFC 7638 thumbprint of a key, the hash of its required members in lexicographic order.
+func thumbprint(jwk JWK) string {
+	var members string
+	switch jwk.Kty {
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS returns the public keys tokens are verified with, empty when tokens are signed with the JWT secret.
+func JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, len(jwtKeys.published))}
+	for i, key := range jwtKeys.published {
+		set.Keys[i] = key.jwk()
+	}
+
+	return set
+}
+
+// GenerateJWT returns an access token of the user for the given session, valid for ttl.
+func GenerateJWT(id, sessionID string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := jwtClaims{
 		id,
+		sessionID,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if jwtKeys.signing == nil {
+		if jwtKeys.secret == nil {
+			return "", errors.New("no key to sign tokens with")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKeys.secret)
+	}
 
-	t, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	token := jwt.NewWithClaims(jwtKeys.signing.method, claims)
+	token.Header["kid"] = jwtKeys.signing.kid
+
+	t, err := token.SignedString(jwtKeys.signing.private)
 	if err != nil {
 		return "", err
 	}
@@ -33,10 +232,23 @@ func GenerateJWT(id string) (string, error) {
 
 func ParseJWT(token string) (*jwtClaims, error) {
 	t, err := jwt.ParseWithClaims(token, &jwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if jwtKeys.secret != nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("invalid signing method")
+			}
+			return jwtKeys.secret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// the algorithm is the one of the key, never the one the token claims.
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return key.public, nil
 	})
 
 	if err != nil {
@@ -47,6 +259,9 @@ func ParseJWT(token string) (*jwtClaims, error) {
 	if !ok {
 		return claims, errors.New("there's something wrong when processing the token")
 	}
+	if claims.SessionID == "" {
+		return claims, errors.New("token has no session, please login again")
+	}
 
 	return claims, nil
 }
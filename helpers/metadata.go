@@ -0,0 +1,418 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+type ImageMetadata struct {
+	CameraMake   string
+	CameraModel  string
+	LensModel    string
+	Software     string
+	FocalLength  *float64
+	Aperture     *float64
+	ExposureTime string
+	ISO          *int
+	Orientation  int
+	TakenAt      *time.Time
+	Latitude     *float64
+	Longitude    *float64
+	Altitude     *float64
+}
+
+const (
+	blockExif = "exif"
+	blockXMP  = "xmp"
+	// any other metadata that doesn't affect how the image is displayed (comments, IPTC, text chunks, etc).
+	blockOther = "other"
+)
+
+// metadataBlock describes where a metadata block lives inside an image file.
+// [start, end) is the whole container segment/chunk, [dataStart, dataEnd) is the metadata payload
+// (a TIFF structure for EXIF, an XML packet for XMP).
+type metadataBlock struct {
+	kind      string
+	start     int
+	end       int
+	dataStart int
+	dataEnd   int
+}
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+func imageContainer(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return "jpeg"
+	case bytes.HasPrefix(data, pngSignature):
+		return "png"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	default:
+		return ""
+	}
+}
+
+// metadataBlocks finds metadata blocks of a JPEG, PNG or WebP file, in the order they appear.
+// other formats (or malformed files) simply have no blocks.
+func metadataBlocks(data []byte) []metadataBlock {
+	switch imageContainer(data) {
+	case "jpeg":
+		return jpegMetadataBlocks(data)
+	case "png":
+		return pngMetadataBlocks(data)
+	case "webp":
+		return webpMetadataBlocks(data)
+	default:
+		return nil
+	}
+}
+
+func jpegMetadataBlocks(data []byte) []metadataBlock {
+	var blocks []metadataBlock
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			break
+		}
+		marker := data[i+1]
+		// start of scan: the rest is compressed image data, no more metadata segments.
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		// fill byte.
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// markers without a length.
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		payload := data[i+4 : end]
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(payload, jpegExifHeader):
+			blocks = append(blocks, metadataBlock{blockExif, i, end, i + 4 + len(jpegExifHeader), end})
+		case marker == 0xE1 && bytes.HasPrefix(payload, jpegXMPHeader):
+			blocks = append(blocks, metadataBlock{blockXMP, i, end, i + 4 + len(jpegXMPHeader), end})
+		// APP1 (extended XMP, etc), APP12 (picture info), APP13 (IPTC) and comments.
+		case marker == 0xE1 || marker == 0xEC || marker == 0xED || marker == 0xFE:
+			blocks = append(blocks, metadataBlock{blockOther, i, end, i + 4, end})
+		}
+
+		i = end
+	}
+
+	return blocks
+}
+
+func pngMetadataBlocks(data []byte) []metadataBlock {
+	var blocks []metadataBlock
+
+	for i := len(pngSignature); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if end > len(data) {
+			break
+		}
+
+		chunkType := string(data[i+4 : i+8])
+		payload := data[i+8 : i+8+length]
+		switch chunkType {
+		case "eXIf":
+			blocks = append(blocks, metadataBlock{blockExif, i, end, i + 8, i + 8 + length})
+		case "iTXt":
+			// keyword, null, compression flag, compression method, language tag, null, translated keyword, null, text.
+			keyword, rest, _ := bytes.Cut(payload, []byte{0})
+			if string(keyword) != "XML:com.adobe.xmp" || len(rest) < 2 || rest[0] != 0 {
+				blocks = append(blocks, metadataBlock{blockOther, i, end, i + 8, i + 8 + length})
+				break
+			}
+			if _, rest, ok := bytes.Cut(rest[2:], []byte{0}); ok {
+				if _, text, ok := bytes.Cut(rest, []byte{0}); ok {
+					blocks = append(blocks, metadataBlock{blockXMP, i, end, i + 8 + length - len(text), i + 8 + length})
+				}
+			}
+		case "tEXt", "zTXt":
+			blocks = append(blocks, metadataBlock{blockOther, i, end, i + 8, i + 8 + length})
+		case "IEND":
+			return blocks
+		}
+
+		i = end
+	}
+
+	return blocks
+}
+
+func webpMetadataBlocks(data []byte) []metadataBlock {
+	var blocks []metadataBlock
+
+	for i := 12; i+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+length > len(data) {
+			break
+		}
+		// chunks are padded to an even size.
+		end := min(i+8+length+length%2, len(data))
+
+		switch string(data[i : i+4]) {
+		case "EXIF":
+			dataStart := i + 8
+			// some encoders keep the JPEG style header inside the chunk.
+			if bytes.HasPrefix(data[dataStart:], jpegExifHeader) {
+				dataStart += len(jpegExifHeader)
+			}
+			blocks = append(blocks, metadataBlock{blockExif, i, end, dataStart, i + 8 + length})
+		case "XMP ":
+			blocks = append(blocks, metadataBlock{blockXMP, i, end, i + 8, i + 8 + length})
+		}
+
+		i = end
+	}
+
+	return blocks
+}
+
+// ExtractMetadata reads the camera, exposure, capture time and location data of an image from its EXIF and
+// XMP metadata. values found in EXIF take precedence over XMP ones. missing metadata isn't an error, the
+// corresponding fields are simply left empty.
+func ExtractMetadata(data []byte) ImageMetadata {
+	var meta ImageMetadata
+
+	for _, block := range metadataBlocks(data) {
+		payload := data[block.dataStart:block.dataEnd]
+		switch block.kind {
+		case blockExif:
+			if x, err := exif.Decode(bytes.NewReader(payload)); x != nil && (err == nil || !exif.IsCriticalError(err)) {
+				readExif(x, &meta)
+			}
+		case blockXMP:
+			readXMP(payload, &meta)
+		}
+	}
+
+	return meta
+}
+
+func readExif(x *exif.Exif, meta *ImageMetadata) {
+	meta.CameraMake = firstNonEmpty(meta.CameraMake, exifString(x, exif.Make))
+	meta.CameraModel = firstNonEmpty(meta.CameraModel, exifString(x, exif.Model))
+	meta.LensModel = firstNonEmpty(meta.LensModel, exifString(x, exif.LensModel))
+	meta.Software = firstNonEmpty(meta.Software, exifString(x, exif.Software))
+
+	if v, ok := exifFloat(x, exif.FocalLength); ok {
+		meta.FocalLength = &v
+	}
+	if v, ok := exifFloat(x, exif.FNumber); ok {
+		meta.Aperture = &v
+	}
+	if tag, err := x.Get(exif.ExposureTime); err == nil && tag.Format() == tiff.RatVal {
+		if r, err := tag.Rat(0); err == nil {
+			meta.ExposureTime = formatExposure(r)
+		}
+	}
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if v, err := tag.Int(0); err == nil {
+			meta.ISO = &v
+		}
+	}
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if v, err := tag.Int(0); err == nil {
+			meta.Orientation = v
+		}
+	}
+	if t, err := x.DateTime(); err == nil {
+		meta.TakenAt = &t
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		meta.Latitude, meta.Longitude = &lat, &long
+	}
+	if v, ok := exifFloat(x, exif.GPSAltitude); ok {
+		if tag, err := x.Get(exif.GPSAltitudeRef); err == nil {
+			if ref, err := tag.Int(0); err == nil && ref == 1 {
+				v = -v
+			}
+		}
+		meta.Altitude = &v
+	}
+}
+
+func exifString(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+
+	s, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
+
+func exifFloat(x *exif.Exif, name exif.FieldName) (float64, bool) {
+	tag, err := x.Get(name)
+	if err != nil || tag.Format() != tiff.RatVal {
+		return 0, false
+	}
+
+	r, err := tag.Rat(0)
+	if err != nil {
+		return 0, false
+	}
+
+	f, _ := r.Float64()
+	return f, true
+}
+
+// formatExposure formats an exposure time the way cameras display it (ex: 1/250, 2.5).
+func formatExposure(r *big.Rat) string {
+	if r.Sign() <= 0 {
+		return ""
+	}
+	if r.Cmp(big.NewRat(1, 1)) < 0 {
+		inv, _ := new(big.Rat).Inv(r).Float64()
+		return "1/" + strconv.FormatFloat(math.Round(inv), 'f', -1, 64)
+	}
+
+	f, _ := r.Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// readXMP collects the fields we're interested in from an XMP packet. properties can be written either as
+// attributes (<rdf:Description tiff:Make="...">) or as elements (<tiff:Make>...</tiff:Make>).
+func readXMP(packet []byte, meta *ImageMetadata) {
+	values := make(map[string]string)
+
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	d.Strict = false
+
+	var current string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if _, ok := values[attr.Name.Local]; !ok {
+					values[attr.Name.Local] = strings.TrimSpace(attr.Value)
+				}
+			}
+			// values of rdf:Seq/rdf:Alt/rdf:Bag are stored inside rdf:li, keep the parent property name.
+			if t.Name.Local != "li" && t.Name.Local != "Seq" && t.Name.Local != "Alt" && t.Name.Local != "Bag" {
+				current = t.Name.Local
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if _, ok := values[current]; !ok && current != "" && text != "" {
+				values[current] = text
+			}
+		case xml.EndElement:
+			if t.Name.Local == current {
+				current = ""
+			}
+		}
+	}
+
+	meta.CameraMake = firstNonEmpty(meta.CameraMake, values["Make"])
+	meta.CameraModel = firstNonEmpty(meta.CameraModel, values["Model"])
+	meta.LensModel = firstNonEmpty(meta.LensModel, values["LensModel"], values["Lens"])
+	meta.Software = firstNonEmpty(meta.Software, values["CreatorTool"], values["Software"])
+
+	if meta.TakenAt == nil {
+		for _, key := range []string{"DateTimeOriginal", "DateCreated", "CreateDate"} {
+			if t, ok := parseXMPDate(values[key]); ok {
+				meta.TakenAt = &t
+				break
+			}
+		}
+	}
+
+	if meta.Latitude == nil || meta.Longitude == nil {
+		lat, okLat := parseXMPCoordinate(values["GPSLatitude"])
+		long, okLong := parseXMPCoordinate(values["GPSLongitude"])
+		if okLat && okLong {
+			meta.Latitude, meta.Longitude = &lat, &long
+		}
+	}
+}
+
+func parseXMPDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseXMPCoordinate parses XMP GPS coordinates, written as "DDD,MM,SSk" or "DDD,MM.mmk"
+// where k is one of N, S, E or W.
+func parseXMPCoordinate(s string) (float64, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+
+	ref := s[len(s)-1]
+	parts := strings.Split(s[:len(s)-1], ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	var v float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		v += f / [3]float64{1, 60, 3600}[i]
+	}
+
+	switch ref {
+	case 'S', 's', 'W', 'w':
+		v = -v
+	case 'N', 'n', 'E', 'e':
+	default:
+		return 0, false
+	}
+
+	return v, true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
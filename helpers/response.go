@@ -7,5 +7,50 @@ type ErrorResponse struct {
 }
 
 type PhotosResponse struct {
-	Photos []dtos.PhotoResponse `json:"photos"`
+	Photos     []dtos.PhotoResponse `json:"photos"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+	PrevCursor string               `json:"prev_cursor,omitempty"`
+
+	// ETag is the entity tag of the page, sent as a header.
+	ETag string `json:"-"`
+	// Public is set when every photo of the page is public, the response doesn't hold signed URLs then.
+	Public bool `json:"-"`
+}
+
+type SearchResponse struct {
+	Photos   []dtos.PhotoResponse `json:"photos"`
+	NextPage int                  `json:"next_page,omitempty"`
+
+	// ETag is the entity tag of the page, sent as a header.
+	ETag string `json:"-"`
+	// Public is set when every photo of the page is public, the response doesn't hold signed URLs then.
+	Public bool `json:"-"`
+}
+
+type PhotoVersionsResponse struct {
+	Versions []dtos.PhotoVersionResponse `json:"versions"`
+}
+
+type TrashResponse struct {
+	Photos []dtos.TrashedPhotoResponse `json:"photos"`
+}
+
+type TagsResponse struct {
+	Tags []dtos.TagResponse `json:"tags"`
+}
+
+type AlbumsResponse struct {
+	Albums []dtos.AlbumResponse `json:"albums"`
+}
+
+type ShareLinksResponse struct {
+	ShareLinks []dtos.ShareLinkResponse `json:"share_links"`
+}
+
+type SessionsResponse struct {
+	Sessions []dtos.SessionResponse `json:"sessions"`
+}
+
+type ShareLinkUsesResponse struct {
+	Uses []dtos.ShareLinkUseResponse `json:"uses"`
 }
@@ -0,0 +1,294 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"regexp"
+)
+
+const (
+	// StripNone keeps every metadata as is.
+	StripNone = "none"
+	// StripGPS removes location data and device serial numbers, other metadata is kept.
+	StripGPS = "gps"
+	// StripAll removes every metadata, except the orientation needed to display the image correctly.
+	StripAll = "all"
+)
+
+const (
+	tagOrientation   = 0x0112
+	tagExifIFD       = 0x8769
+	tagGPSIFD        = 0x8825
+	tagMakerNote     = 0x927C
+	tagImageUniqueID = 0xA420
+	tagOwnerName     = 0xA430
+	tagBodySerial    = 0xA431
+	tagLensSerial    = 0xA435
+	tagCameraSerial  = 0xC62F
+)
+
+// sensitiveTags are blanked in StripGPS mode. the maker note is included since most vendors store
+// the camera serial number (and sometimes location) in it.
+var sensitiveTags = map[uint16]bool{
+	tagMakerNote:     true,
+	tagImageUniqueID: true,
+	tagOwnerName:     true,
+	tagBodySerial:    true,
+	tagLensSerial:    true,
+	tagCameraSerial:  true,
+}
+
+var xmpSensitiveRe = regexp.MustCompile(`\s[\w-]+:(?:GPS\w*|\w*SerialNumber|OwnerName|ImageUniqueID)="[^"]*"|<([\w-]+:(?:GPS\w*|\w*SerialNumber|OwnerName|ImageUniqueID))\b[^>]*>[\s\S]*?</([\w-]+:(?:GPS\w*|\w*SerialNumber|OwnerName|ImageUniqueID))>`)
+
+// StripMetadata returns a copy of a JPEG, PNG or WebP image without its sensitive metadata, according to mode.
+// the pixel data is never re-encoded. other formats are returned as is.
+func StripMetadata(data []byte, mode string) []byte {
+	if mode == StripNone {
+		return data
+	}
+
+	blocks := metadataBlocks(data)
+	if len(blocks) == 0 {
+		return data
+	}
+
+	container := imageContainer(data)
+	out := make([]byte, 0, len(data))
+	last := 0
+	for _, block := range blocks {
+		out = append(out, data[last:block.start]...)
+		last = block.end
+
+		if mode == StripGPS {
+			segment := bytes.Clone(data[block.start:block.end])
+			payload := segment[block.dataStart-block.start : block.dataEnd-block.start]
+			switch block.kind {
+			case blockExif:
+				scrubExif(payload)
+			case blockXMP:
+				scrubXMP(payload)
+			}
+			if container == "png" {
+				updatePNGChecksum(segment)
+			}
+			out = append(out, segment...)
+			continue
+		}
+
+		// keep the orientation, otherwise the photo may be displayed rotated.
+		if block.kind == blockExif {
+			if orientation := exifOrientation(data[block.dataStart:block.dataEnd]); orientation > 1 {
+				out = append(out, exifSegment(container, orientationExif(orientation))...)
+			}
+		}
+	}
+	out = append(out, data[last:]...)
+
+	if container == "webp" {
+		fixWebPHeader(out)
+	}
+
+	return out
+}
+
+// tiffReader gives a bounds checked access to the IFD entries of a TIFF structure (the EXIF payload).
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffTypeSizes is the size in bytes of every TIFF field type, indexed by the type ID.
+var tiffTypeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+type tiffEntry struct {
+	tag    uint16
+	offset int // offset of the 12 bytes entry.
+	valPos int // offset of the value, either inside the entry or somewhere else in the payload.
+	valLen int
+}
+
+func newTIFFReader(data []byte) (tiffReader, bool) {
+	if len(data) < 8 {
+		return tiffReader{}, false
+	}
+
+	switch string(data[:4]) {
+	case "II*\x00":
+		return tiffReader{data, binary.LittleEndian}, true
+	case "MM\x00*":
+		return tiffReader{data, binary.BigEndian}, true
+	default:
+		return tiffReader{}, false
+	}
+}
+
+func (t tiffReader) firstIFD() int {
+	return int(t.order.Uint32(t.data[4:]))
+}
+
+// entries returns the entries of the IFD at offset. entries pointing outside the payload are skipped.
+func (t tiffReader) entries(offset int) []tiffEntry {
+	if offset < 8 || offset+2 > len(t.data) {
+		return nil
+	}
+
+	count := int(t.order.Uint16(t.data[offset:]))
+	entries := make([]tiffEntry, 0, count)
+	for i := 0; i < count; i++ {
+		pos := offset + 2 + i*12
+		if pos+12 > len(t.data) {
+			break
+		}
+
+		typ := int(t.order.Uint16(t.data[pos+2:]))
+		if typ >= len(tiffTypeSizes) {
+			continue
+		}
+
+		valLen := tiffTypeSizes[typ] * int(t.order.Uint32(t.data[pos+4:]))
+		valPos := pos + 8
+		if valLen > 4 {
+			valPos = int(t.order.Uint32(t.data[pos+8:]))
+		}
+		if valLen < 0 || valPos < 0 || valPos+valLen > len(t.data) {
+			continue
+		}
+
+		entries = append(entries, tiffEntry{t.order.Uint16(t.data[pos:]), pos, valPos, valLen})
+	}
+
+	return entries
+}
+
+func (t tiffReader) pointer(e tiffEntry) int {
+	if e.valLen < 4 {
+		return 0
+	}
+	return int(t.order.Uint32(t.data[e.valPos:]))
+}
+
+// scrubExif blanks, in place, the GPS IFD and sensitive tags of an EXIF payload.
+// nothing is moved around, so every offset stays valid.
+func scrubExif(data []byte) {
+	t, ok := newTIFFReader(data)
+	if !ok {
+		return
+	}
+
+	ifd0 := t.entries(t.firstIFD())
+	for _, e := range ifd0 {
+		switch {
+		case e.tag == tagGPSIFD:
+			gps := t.pointer(e)
+			for _, g := range t.entries(gps) {
+				clear(data[g.valPos : g.valPos+g.valLen])
+				clear(data[g.offset : g.offset+12])
+			}
+			// the GPS IFD is kept, but without any entry.
+			if gps >= 8 && gps+2 <= len(data) {
+				clear(data[gps : gps+2])
+			}
+		case e.tag == tagExifIFD:
+			for _, x := range t.entries(t.pointer(e)) {
+				if sensitiveTags[x.tag] {
+					clear(data[x.valPos : x.valPos+x.valLen])
+				}
+			}
+		case sensitiveTags[e.tag]:
+			clear(data[e.valPos : e.valPos+e.valLen])
+		}
+	}
+}
+
+// scrubXMP replaces, in place, location and serial number properties of an XMP packet with spaces.
+func scrubXMP(data []byte) {
+	for _, loc := range xmpSensitiveRe.FindAllIndex(data, -1) {
+		for i := loc[0]; i < loc[1]; i++ {
+			data[i] = ' '
+		}
+	}
+}
+
+func exifOrientation(data []byte) int {
+	t, ok := newTIFFReader(data)
+	if !ok {
+		return 0
+	}
+
+	for _, e := range t.entries(t.firstIFD()) {
+		if e.tag == tagOrientation && e.valLen >= 2 {
+			return int(t.order.Uint16(data[e.valPos:]))
+		}
+	}
+
+	return 0
+}
+
+// orientationExif builds an EXIF payload that only contains the orientation tag.
+func orientationExif(orientation int) []byte {
+	b := []byte("MM\x00*\x00\x00\x00\x08")
+	b = binary.BigEndian.AppendUint16(b, 1)
+	b = binary.BigEndian.AppendUint16(b, tagOrientation)
+	b = binary.BigEndian.AppendUint16(b, 3)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint16(b, uint16(orientation))
+	b = binary.BigEndian.AppendUint16(b, 0)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	return b
+}
+
+// exifSegment wraps an EXIF payload into a JPEG APP1 segment, a PNG eXIf chunk or a WebP EXIF chunk.
+func exifSegment(container string, payload []byte) []byte {
+	var b []byte
+	switch container {
+	case "jpeg":
+		b = append(b, 0xFF, 0xE1)
+		b = binary.BigEndian.AppendUint16(b, uint16(2+len(jpegExifHeader)+len(payload)))
+		b = append(b, jpegExifHeader...)
+		b = append(b, payload...)
+	case "png":
+		b = binary.BigEndian.AppendUint32(b, uint32(len(payload)))
+		b = append(b, "eXIf"...)
+		b = append(b, payload...)
+		b = append(b, 0, 0, 0, 0)
+		updatePNGChecksum(b)
+	case "webp":
+		b = append(b, "EXIF"...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(payload)))
+		b = append(b, payload...)
+		// chunks are padded to an even size.
+		if len(payload)%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	return b
+}
+
+func updatePNGChecksum(chunk []byte) {
+	n := len(chunk)
+	binary.BigEndian.PutUint32(chunk[n-4:], crc32.ChecksumIEEE(chunk[4:n-4]))
+}
+
+// fixWebPHeader updates the RIFF size and the VP8X metadata flags after chunks are removed.
+func fixWebPHeader(data []byte) {
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	if len(data) < 30 || string(data[12:16]) != "VP8X" {
+		return
+	}
+
+	var hasExif, hasXMP bool
+	for _, block := range webpMetadataBlocks(data) {
+		hasExif = hasExif || block.kind == blockExif
+		hasXMP = hasXMP || block.kind == blockXMP
+	}
+
+	const exifFlag, xmpFlag = 0x08, 0x04
+	if !hasExif {
+		data[20] &^= exifFlag
+	}
+	if !hasXMP {
+		data[20] &^= xmpFlag
+	}
+}
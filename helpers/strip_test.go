@@ -0,0 +1,233 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+const (
+	testMake   = "TestMake"
+	testSerial = "SN12345"
+	testTool   = "TestTool"
+)
+
+var testXMP = []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" ` +
+	`xmp:CreatorTool="` + testTool + `" exif:GPSLatitude="48,51.5N" exif:GPSLongitude="2,21.0E"/>` +
+	`</rdf:RDF></x:xmpmeta>`)
+
+// webpImage is a 1x1 lossless WebP image.
+var webpImage = []byte("VP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+// testExif builds a big endian EXIF payload with a make, an orientation, a body serial number in the EXIF IFD
+// and a location in the GPS IFD.
+func testExif() []byte {
+	be := binary.BigEndian
+	entry := func(b []byte, tag, typ uint16, count, value uint32) []byte {
+		b = be.AppendUint16(b, tag)
+		b = be.AppendUint16(b, typ)
+		b = be.AppendUint32(b, count)
+		return be.AppendUint32(b, value)
+	}
+	const (
+		ifd0    uint32 = 8
+		makeVal        = ifd0 + 2 + 4*12 + 4
+		exifIFD        = makeVal + uint32(len(testMake)) + 1
+		serial         = exifIFD + 2 + 12 + 4
+		gpsIFD         = serial + uint32(len(testSerial)) + 1
+		gpsData        = gpsIFD + 2 + 4*12 + 4
+	)
+
+	b := []byte("MM\x00*\x00\x00\x00\x08")
+	b = be.AppendUint16(b, 4)
+	b = entry(b, 0x010F, 2, uint32(len(testMake)+1), makeVal)
+	b = entry(b, tagOrientation, 3, 1, 6<<16)
+	b = entry(b, tagExifIFD, 4, 1, exifIFD)
+	b = entry(b, tagGPSIFD, 4, 1, gpsIFD)
+	b = be.AppendUint32(b, 0)
+	b = append(b, testMake+"\x00"...)
+
+	b = be.AppendUint16(b, 1)
+	b = entry(b, tagBodySerial, 2, uint32(len(testSerial)+1), serial)
+	b = be.AppendUint32(b, 0)
+	b = append(b, testSerial+"\x00"...)
+
+	b = be.AppendUint16(b, 4)
+	b = entry(b, 1, 2, 2, 'N'<<24)
+	b = entry(b, 2, 5, 3, gpsData)
+	b = entry(b, 3, 2, 2, 'E'<<24)
+	b = entry(b, 4, 5, 3, gpsData+24)
+	b = be.AppendUint32(b, 0)
+	for _, v := range []uint32{48, 1, 51, 1, 30, 1, 2, 1, 21, 1, 0, 1} {
+		b = be.AppendUint32(b, v)
+	}
+
+	return b
+}
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 32), uint8(y * 32), 128, 255})
+		}
+	}
+	return img
+}
+
+func testJPEG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	segment := func(header string, payload []byte) []byte {
+		b := []byte{0xFF, 0xE1}
+		b = binary.BigEndian.AppendUint16(b, uint16(2+len(header)+len(payload)))
+		b = append(b, header...)
+		return append(b, payload...)
+	}
+
+	out := []byte{0xFF, 0xD8}
+	out = append(out, segment("Exif\x00\x00", testExif())...)
+	out = append(out, segment("http://ns.adobe.com/xap/1.0/\x00", testXMP)...)
+	return append(out, buf.Bytes()[2:]...)
+}
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+
+	chunk := func(typ string, payload []byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+		b = append(b, typ...)
+		b = append(b, payload...)
+		return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+	}
+
+	// the metadata chunks go right after IHDR.
+	data := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + int(binary.BigEndian.Uint32(data[len(pngSignature):]))
+	out := bytes.Clone(data[:ihdrEnd])
+	out = append(out, chunk("eXIf", testExif())...)
+	out = append(out, chunk("iTXt", append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), testXMP...))...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func testWebP(t *testing.T) []byte {
+	chunk := func(typ string, payload []byte) []byte {
+		b := append([]byte(typ), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+		b = append(b, payload...)
+		if len(payload)%2 == 1 {
+			b = append(b, 0)
+		}
+		return b
+	}
+
+	const exifFlag, xmpFlag = 0x08, 0x04
+	out := []byte("RIFF\x00\x00\x00\x00WEBP")
+	// a 1x1 canvas, its size is stored minus one.
+	out = append(out, chunk("VP8X", []byte{exifFlag | xmpFlag, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	out = append(out, webpImage...)
+	out = append(out, chunk("EXIF", testExif())...)
+	out = append(out, chunk("XMP ", testXMP)...)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+func TestStripMetadata(t *testing.T) {
+	formats := []struct {
+		name  string
+		build func(*testing.T) []byte
+	}{
+		{"jpeg", testJPEG},
+		{"png", testPNG},
+		{"webp", testWebP},
+	}
+
+	for _, format := range formats {
+		data := format.build(t)
+
+		// the fixture itself has to carry everything the tests below expect to be removed.
+		meta := ExtractMetadata(data)
+		if meta.Latitude == nil || meta.CameraMake != testMake || meta.Orientation != 6 || !bytes.Contains(data, []byte(testSerial)) {
+			t.Fatalf("%s: invalid fixture, got %+v", format.name, meta)
+		}
+
+		tests := []struct {
+			mode string
+			// present and absent are byte sequences expected in the output or not.
+			present [][]byte
+			absent  [][]byte
+			make    string
+		}{
+			{
+				mode:    StripNone,
+				present: [][]byte{[]byte(testSerial), []byte("GPSLatitude"), []byte(testTool)},
+				make:    testMake,
+			},
+			{
+				mode:    StripGPS,
+				present: [][]byte{[]byte(testMake), []byte(testTool)},
+				absent:  [][]byte{[]byte(testSerial), []byte("GPSLatitude"), []byte("GPSLongitude")},
+				make:    testMake,
+			},
+			{
+				mode:   StripAll,
+				absent: [][]byte{[]byte(testMake), []byte(testSerial), []byte(testTool), []byte("GPSLatitude"), []byte("xmpmeta")},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.mode, func(t *testing.T) {
+				out := StripMetadata(data, tt.mode)
+
+				img, name, err := image.Decode(bytes.NewReader(out))
+				if err != nil {
+					t.Fatalf("stripped image doesn't decode: %v", err)
+				}
+				if name != format.name || img.Bounds() != mustDecode(t, data).Bounds() {
+					t.Errorf("stripped image is a %s of %v", name, img.Bounds())
+				}
+
+				meta := ExtractMetadata(out)
+				if tt.mode != StripNone && (meta.Latitude != nil || meta.Longitude != nil) {
+					t.Error("location survived")
+				}
+				if meta.CameraMake != tt.make {
+					t.Errorf("camera make is %q, want %q", meta.CameraMake, tt.make)
+				}
+				if meta.Orientation != 6 {
+					t.Errorf("orientation is %d, want 6", meta.Orientation)
+				}
+
+				for _, b := range tt.present {
+					if !bytes.Contains(out, b) {
+						t.Errorf("%q was removed", b)
+					}
+				}
+				for _, b := range tt.absent {
+					if bytes.Contains(out, b) {
+						t.Errorf("%q survived", b)
+					}
+				}
+			})
+		}
+	}
+}
+
+func mustDecode(t *testing.T, data []byte) image.Image {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
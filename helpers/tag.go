@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	MaxTagLength = 50
+	MaxPhotoTags = 20
+)
+
+// NormalizeTag turns a free-form tag into a lowercase slug: letters and digits are kept, everything else
+// collapses into a single dash. it returns an empty string when nothing is left.
+func NormalizeTag(tag string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(tag)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	slug := b.String()
+	if len(slug) > MaxTagLength {
+		slug = strings.TrimRight(strings.ToValidUTF8(slug[:MaxTagLength], ""), "-")
+	}
+
+	return slug
+}
+
+// NormalizeTags normalizes every tag, a value may hold several comma separated tags.
+// empty and duplicate tags are dropped, the order of the first occurrence is kept.
+func NormalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, value := range tags {
+		for _, tag := range strings.Split(value, ",") {
+			slug := NormalizeTag(tag)
+			if slug == "" || seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			res = append(res, slug)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,57 @@
+package helpers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	signer := NewURLSigner(Photo{URLSigningKey: "url-key", URLTTL: 15 * time.Minute})
+
+	signed := signer.Sign("/files/p1/thumb", now)
+	filePath, query, _ := strings.Cut(signed, "?")
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, signature := q.Get("expires"), q.Get("signature")
+
+	// the expiry is rounded down to the minute, so URLs signed within the same minute are the same.
+	if again := signer.Sign("/files/p1/thumb", now.Add(10*time.Second)); again != signed {
+		t.Errorf("signed twice in the same minute: %q and %q", signed, again)
+	}
+
+	tests := []struct {
+		name      string
+		signer    URLSigner
+		path      string
+		expires   string
+		signature string
+		now       time.Time
+		err       error
+	}{
+		{"valid", signer, filePath, expires, signature, now, nil},
+		{"valid until expiry", signer, filePath, expires, signature, now.Add(14 * time.Minute), nil},
+		{"expired", signer, filePath, expires, signature, now.Add(15 * time.Minute), ErrInvalidSignature},
+		{"extended expiry", signer, filePath, "9999999999", signature, now.Add(time.Hour), ErrInvalidSignature},
+		{"tampered signature", signer, filePath, expires, signature[:len(signature)-1] + "A", now, ErrInvalidSignature},
+		{"other path", signer, "/files/p2/thumb", expires, signature, now, ErrInvalidSignature},
+		{"other variant", signer, "/files/p1", expires, signature, now, ErrInvalidSignature},
+		{"wrong key", NewURLSigner(Photo{URLSigningKey: "other-key", URLTTL: 15 * time.Minute}), filePath, expires, signature, now, ErrInvalidSignature},
+		{"missing signature", signer, filePath, expires, "", now, ErrInvalidSignature},
+		{"invalid expiry", signer, filePath, "soon", signature, now, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.path, tt.expires, tt.signature, tt.now)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
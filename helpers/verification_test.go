@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailVerifier(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewEmailVerifier(Auth{EmailTokenKey: "email-key", EmailTokenTTL: 48 * time.Hour})
+
+	token := verifier.Token("u1", "alice@example.org", now)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// a token for the new address, with the signature of the one sent to the old address.
+	forged := base64.RawURLEncoding.EncodeToString([]byte("u1\nalice@example.com\n9999999999")) + "." + signature
+
+	// the same key without the purpose, as another signer sharing the key would use it.
+	mac := hmac.New(sha256.New, []byte("email-key"))
+	mac.Write([]byte(payload))
+	unscoped := payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name   string
+		token  string
+		now    time.Time
+		userID string
+		email  string
+		err    error
+	}{
+		{name: "valid", token: token, now: now, userID: "u1", email: "alice@example.org"},
+		{name: "valid until expiry", token: token, now: now.Add(48*time.Hour - time.Second), userID: "u1", email: "alice@example.org"},
+		{name: "expired", token: token, now: now.Add(48 * time.Hour), err: ErrInvalidVerificationToken},
+		{name: "forged address", token: forged, now: now, err: ErrInvalidVerificationToken},
+		{name: "tampered signature", token: payload + "." + strings.ToUpper(signature), now: now, err: ErrInvalidVerificationToken},
+		{name: "wrong key", token: NewEmailVerifier(Auth{EmailTokenKey: "other-key", EmailTokenTTL: time.Hour}).Token("u1", "alice@example.org", now), now: now, err: ErrInvalidVerificationToken},
+		{name: "other purpose", token: unscoped, now: now, err: ErrInvalidVerificationToken},
+		{name: "no signature", token: payload, now: now, err: ErrInvalidVerificationToken},
+		{name: "empty", token: "", now: now, err: ErrInvalidVerificationToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, email, err := verifier.Verify(tt.token, tt.now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if userID != tt.userID || email != tt.email {
+				t.Errorf("got %q, %q, want %q, %q", userID, email, tt.userID, tt.email)
+			}
+		})
+	}
+
+	// the user changes their email after the token was sent: the token still names the old address, which the
+	// caller compares to the current one, and a token for the new address is a different one.
+	t.Run("replayed after email change", func(t *testing.T) {
+		_, email, err := verifier.Verify(token, now.Add(time.Hour))
+		if err != nil || email != "alice@example.org" {
+			t.Fatalf("got %q, %v", email, err)
+		}
+
+		renewed := verifier.Token("u1", "alice@example.com", now.Add(time.Hour))
+		if renewed == token {
+			t.Fatal("the token of the new address is the token of the old one")
+		}
+		if _, email, err := verifier.Verify(renewed, now.Add(time.Hour)); err != nil || email != "alice@example.com" {
+			t.Errorf("got %q, %v", email, err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/helpers"
+	"photo-app/repositories"
+	"photo-app/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FileCleaner retries the pending file deletions and periodically reports the files that are out of sync with the database.
+type FileCleaner struct {
+	c                 controllers.FileController
+	deletionInterval  time.Duration
+	reconcileInterval time.Duration
+	logger            *slog.Logger
+}
+
+func NewFileCleaner(db *gorm.DB, store storage.Storage, conf helpers.Storage, logger *slog.Logger) *FileCleaner {
+	repo := repositories.NewFileRepository(db)
+	controller := controllers.NewFileController(repo, store, logger)
+
+	return &FileCleaner{controller, conf.DeletionInterval, conf.ReconcileInterval, logger}
+}
+
+// Run processes the deletions and reconciles the files right away and then on their own interval, until ctx is done.
+// a non-positive interval disables the matching task.
+func (j *FileCleaner) Run(ctx context.Context) {
+	if j.deletionInterval > 0 {
+		go every(ctx, j.deletionInterval, j.processDeletions)
+	}
+	if j.reconcileInterval > 0 {
+		go every(ctx, j.reconcileInterval, j.reconcile)
+	}
+}
+
+func (j *FileCleaner) processDeletions(ctx context.Context) {
+	processed, err := j.c.ProcessDeletions(ctx)
+	if err != nil {
+		j.logger.Error("Jobs [PROCESS FILE DELETIONS]", "error", err.Error())
+	}
+	if processed > 0 {
+		j.logger.Info("Jobs [PROCESS FILE DELETIONS]", "processed", processed)
+	}
+}
+
+// reconcile only reports the inconsistencies, nothing is deleted since a missing row may be a bug worth looking at.
+func (j *FileCleaner) reconcile(ctx context.Context) {
+	report, err := j.c.Reconcile(ctx)
+	if err != nil {
+		j.logger.Error("Jobs [RECONCILE FILES]", "error", err.Error())
+		return
+	}
+
+	for _, filePath := range report.OrphanedFiles {
+		j.logger.Warn("Jobs [RECONCILE FILES]", "orphaned_file", filePath)
+	}
+	for filePath, photoID := range report.MissingFiles {
+		j.logger.Warn("Jobs [RECONCILE FILES]", "missing_file", filePath, "photo_id", photoID)
+	}
+	j.logger.Info("Jobs [RECONCILE FILES]", "orphaned", len(report.OrphanedFiles), "missing", len(report.MissingFiles))
+}
+
+// every runs fn right away and then on every interval, until ctx is done.
+func every(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
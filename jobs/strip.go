@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/helpers"
+	"photo-app/repositories"
+	"photo-app/storage"
+
+	"gorm.io/gorm"
+)
+
+// MetadataStripper strips the metadata of the files stored before stripping existed, so they stop leaking the
+// location and serial numbers they were uploaded with.
+type MetadataStripper struct {
+	c      controllers.PhotoController
+	logger *slog.Logger
+}
+
+func NewMetadataStripper(db *gorm.DB, store storage.Storage, conf helpers.Photo, logger *slog.Logger) *MetadataStripper {
+	repo := repositories.NewPhotoRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	shareRepo := repositories.NewShareRepository(db)
+	controller := controllers.NewPhotoController(repo, userRepo, fileRepo, shareRepo, store, conf, logger)
+
+	return &MetadataStripper{controller, logger}
+}
+
+// Run strips the remaining files once, it's a no-op as soon as every file has been stripped.
+func (j *MetadataStripper) Run(ctx context.Context) {
+	stripped, err := j.c.StripLegacyFiles(ctx)
+	if err != nil {
+		j.logger.Error("Jobs [STRIP LEGACY FILES]", "error", err.Error())
+	}
+	if stripped > 0 {
+		j.logger.Info("Jobs [STRIP LEGACY FILES]", "stripped", stripped)
+	}
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/helpers"
+	"photo-app/repositories"
+	"photo-app/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashPurger periodically deletes for good the photos that have been in the trash for longer than the retention period.
+type TrashPurger struct {
+	c        controllers.PhotoController
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewTrashPurger(db *gorm.DB, store storage.Storage, conf helpers.Photo, logger *slog.Logger) *TrashPurger {
+	repo := repositories.NewPhotoRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	shareRepo := repositories.NewShareRepository(db)
+	controller := controllers.NewPhotoController(repo, userRepo, fileRepo, shareRepo, store, conf, logger)
+
+	return &TrashPurger{controller, conf.TrashPurgeInterval, logger}
+}
+
+// Run purges the trash right away and then on every interval, until ctx is done. a non-positive interval disables it.
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		return
+	}
+
+	every(ctx, p.interval, p.purge)
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.c.PurgeTrash(ctx)
+	if err != nil {
+		p.logger.Error("Jobs [PURGE TRASH]", "error", err.Error())
+	}
+	if purged > 0 {
+		p.logger.Info("Jobs [PURGE TRASH]", "purged", purged)
+	}
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer writes every message to its own .eml file, so sent messages can be read back in tests.
+type fileMailer struct {
+	from string
+	dir  string
+}
+
+func NewFileMailer(from, dir string) (Mailer, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(root, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileMailer{from, root}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	// the file names sort in the order the messages were sent.
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+
+	return os.WriteFile(filepath.Join(m.dir, name), msg.encode(m.from, now), 0o644)
+}
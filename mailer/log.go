@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logMailer only logs that messages would have been sent, it's meant for development where no mail server is at
+// hand. bodies hold working tokens and are never logged, the file backend keeps them when they're needed.
+type logMailer struct {
+	from   string
+	logger *slog.Logger
+}
+
+func NewLogMailer(from string, logger *slog.Logger) Mailer {
+	logger.Warn("Mail [NEW]", "error", "the log mail backend never sends emails, it must not be used in production")
+	return &logMailer{from, logger}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("Mail [SEND]", "from", m.from, "to", msg.To, "subject", msg.Subject)
+	return nil
+}
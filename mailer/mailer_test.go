@@ -0,0 +1,207 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"photo-app/helpers"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLink = "https://photos.example.org/verify-email?token=eyJ1c2VyIjoiNDIifQ.c2lnbmF0dXJl-_x"
+
+// smtpSink is a minimal SMTP server accepting a single session, it records the commands and the message it receives.
+type smtpSink struct {
+	addr     string
+	commands []string
+	data     string
+	done     chan error
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	sink := &smtpSink{addr: l.Addr().String(), done: make(chan error, 1)}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			sink.done <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		sink.done <- sink.serve(textproto.NewConn(conn))
+	}()
+
+	return sink
+}
+
+func (s *smtpSink) serve(c *textproto.Conn) error {
+	if err := c.PrintfLine("220 localhost ESMTP sink"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return err
+		}
+		s.commands = append(s.commands, line)
+
+		verb, _, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			err = c.PrintfLine("250-localhost\r\n250 8BITMIME")
+		case "DATA":
+			if err = c.PrintfLine("354 go ahead"); err != nil {
+				return err
+			}
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return err
+			}
+			s.data = string(data)
+			err = c.PrintfLine("250 queued")
+		case "QUIT":
+			return c.PrintfLine("221 bye")
+		default:
+			err = c.PrintfLine("250 ok")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port, _ := net.SplitHostPort(sink.addr)
+	p, _ := strconv.Atoi(port)
+
+	m, err := NewSMTPMailer(helpers.Mail{
+		From:     "Photo App <no-reply@photos.example.org>",
+		SMTPHost: host,
+		SMTPPort: uint(p),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = m.Send(ctx, Message{
+		To:      "alice@example.org",
+		Subject: "Vérifiez votre adresse",
+		Body:    "Hi alice,\n\n" + testLink + "\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-sink.done; err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := []string{"MAIL FROM:<no-reply@photos.example.org>", "RCPT TO:<alice@example.org>"}
+	for _, want := range envelope {
+		found := false
+		for _, cmd := range sink.commands {
+			found = found || strings.HasPrefix(cmd, want)
+		}
+		if !found {
+			t.Errorf("%q wasn't sent, got %q", want, sink.commands)
+		}
+	}
+
+	checkMessage(t, sink.data)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer("Photo App <no-reply@photos.example.org>", filepath.Join(dir, "mails"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, to := range []string{"alice@example.org", "bob@example.org"} {
+		err := m.Send(context.Background(), Message{
+			To:      to,
+			Subject: "Vérifiez votre adresse",
+			Body:    "Hi alice,\n\n" + testLink + "\n",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "mails", "*.eml"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("got %v, %v", files, err)
+	}
+
+	// the files sort in the order the messages were sent.
+	for i, to := range []string{"alice@example.org", "bob@example.org"} {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := checkMessage(t, string(data))
+		if got := msg.Header.Get("To"); got != to {
+			t.Errorf("file %d is sent to %q, want %q", i, got, to)
+		}
+	}
+}
+
+// checkMessage checks the headers of an encoded message and that the link of its body arrived intact.
+func checkMessage(t *testing.T, data string) *mail.Message {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, data)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Vérifiez votre adresse" {
+		t.Errorf("subject is %q, %v", subject, err)
+	}
+	headers := map[string]string{
+		"From":         "Photo App <no-reply@photos.example.org>",
+		"Content-Type": "text/plain; charset=utf-8",
+		"MIME-Version": "1.0",
+	}
+	for name, want := range headers {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("%s is %q, want %q", name, got, want)
+		}
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("invalid date: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@photos.example.org>") {
+		t.Errorf("message ID is %q", id)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the SMTP sink reads the data with bare line feeds.
+	if !strings.Contains(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n"+testLink+"\n") {
+		t.Errorf("the link didn't arrive intact:\n%s", body)
+	}
+
+	return msg
+}
@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"photo-app/helpers"
+	"strconv"
+	"time"
+)
+
+type smtpMailer struct {
+	addr string
+	host string
+	from string
+	// sender is the bare address of from, used as the envelope sender.
+	sender string
+	auth   smtp.Auth
+}
+
+func NewSMTPMailer(conf helpers.Mail) (Mailer, error) {
+	if conf.SMTPHost == "" {
+		return nil, errors.New("MAIL_SMTP_HOST is required with the smtp mail backend")
+	}
+
+	sender, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &smtpMailer{
+		addr:   net.JoinHostPort(conf.SMTPHost, strconv.Itoa(int(conf.SMTPPort))),
+		host:   conf.SMTPHost,
+		from:   conf.From,
+		sender: sender.Address,
+	}
+	// PlainAuth refuses to send credentials over an unencrypted connection, except to localhost.
+	if conf.SMTPUsername != "" {
+		m.auth = smtp.PlainAuth("", conf.SMTPUsername, conf.SMTPPassword, conf.SMTPHost)
+	}
+
+	return m, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// the connection is upgraded whenever the server supports it, local sinks usually don't.
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: m.host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.auth != nil {
+		err = c.Auth(m.auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(m.sender)
+	if err != nil {
+		return err
+	}
+	err = c.Rcpt(msg.To)
+	if err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg.encode(m.from, time.Now()))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
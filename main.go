@@ -5,6 +5,8 @@ import (
 	"photo-app/database"
 	_ "photo-app/docs"
 	"photo-app/helpers"
+	"photo-app/mailer"
+	"photo-app/storage"
 )
 
 //	@title						Photo App
@@ -27,7 +29,17 @@ func main() {
 		panic(err)
 	}
 
-	app := app.New(config.App, db, logger)
+	store, err := storage.New(config.Storage)
+	if err != nil {
+		panic(err)
+	}
+
+	mail, err := mailer.New(config.Mail, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	app := app.New(config, db, store, mail, logger)
 
 	if err := app.Start(); err != nil {
 		panic(err)
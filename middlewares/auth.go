@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
 	"photo-app/helpers"
 	"regexp"
@@ -9,9 +10,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// SessionChecker tells whether the session of an access token is still active.
+type SessionChecker interface {
+	IsActive(context.Context, string) (bool, error)
+}
+
 var re = regexp.MustCompile(`^[A-Za-z0-9-_=]+\.[A-Za-z0-9-_=]+\.?[A-Za-z0-9-_.+/=]*$`)
 
-func AuthMiddleware(strict bool) gin.HandlerFunc {
+func AuthMiddleware(sessions SessionChecker, strict bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token := ctx.Request.Header.Get("Authorization")
 		if token == "" {
@@ -48,7 +54,22 @@ func AuthMiddleware(strict bool) gin.HandlerFunc {
 			return
 		}
 
+		active, err := sessions.IsActive(ctx, claims.SessionID)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": helpers.ErrInternal.Error(),
+			})
+			return
+		}
+		if !active {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "session has been signed out, please login again",
+			})
+			return
+		}
+
 		ctx.Set("id", claims.ID)
+		ctx.Set("sid", claims.SessionID)
 		ctx.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"photo-app/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareMiddleware reads the share link a request is made with, from the X-Share-Token header or the share query
+// parameter. the password of the link is only read from the X-Share-Password header, so it never ends up in access
+// logs, referers or the browser history.
+func ShareMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader("X-Share-Token")
+		if token == "" {
+			token = ctx.Query("share")
+		}
+		if token == "" {
+			ctx.Next()
+			return
+		}
+
+		ctx.Set("share", dtos.ShareAccess{
+			Token:     token,
+			Password:  ctx.GetHeader("X-Share-Password"),
+			IP:        ctx.ClientIP(),
+			UserAgent: ctx.Request.UserAgent(),
+		})
+		ctx.Next()
+	}
+}
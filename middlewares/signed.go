@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+	"photo-app/helpers"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedURLMiddleware checks the signature of requests made with a signed URL, without any database lookup.
+// a valid signature marks the request as signed_url, a request without signature is left to the next handlers.
+func SignedURLMiddleware(signer helpers.URLSigner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		signature := ctx.Query("signature")
+		if signature == "" {
+			ctx.Next()
+			return
+		}
+
+		err := signer.Verify(ctx.Request.URL.Path, ctx.Query("expires"), signature, time.Now())
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx.Set("signed_url", true)
+		ctx.Next()
+	}
+}
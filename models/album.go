@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Album struct {
+	ID           string `gorm:"primaryKey"`
+	Title        string
+	Description  string
+	CoverPhotoID *string
+	UserID       string
+	// Visibility is one of the Visibility* levels, the photos of the album keep their own.
+	Visibility string `gorm:"default:public;index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	User       User
+	CoverPhoto *Photo       `gorm:"foreignKey:CoverPhotoID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Photos     []AlbumPhoto `gorm:"foreignKey:AlbumID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// AlbumPhoto links a photo to an album. Position is the zero-based place of the photo inside the album.
+type AlbumPhoto struct {
+	AlbumID   string `gorm:"primaryKey"`
+	PhotoID   string `gorm:"primaryKey"`
+	Position  int
+	CreatedAt time.Time
+
+	Photo Photo `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// FileDeletion is an outbox entry for files that must be removed from the storage. entries are written in the same
+// transaction as the change that stops referencing the files, and processed once that transaction is committed.
+type FileDeletion struct {
+	ID   uint `gorm:"primaryKey"`
+	Path string
+	// Prefix removes every file whose path starts with Path instead of a single file.
+	Prefix bool
+	// NotBefore delays the deletion. files of an upload are guarded this way until the photo row is committed,
+	// the guard is removed along with the commit and the files are deleted if it never happens.
+	NotBefore time.Time `gorm:"index"`
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Follow means the follower sees the photos the followee shares with their followers.
+type Follow struct {
+	FollowerID string `gorm:"primaryKey"`
+	FolloweeID string `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+
+	Follower User `gorm:"foreignKey:FollowerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Followee User `gorm:"foreignKey:FolloweeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
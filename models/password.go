@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PasswordReset lets the user it was emailed to set a new password once, without knowing the current one.
+type PasswordReset struct {
+	ID uint `gorm:"primaryKey"`
+	// TokenHash is the hash of the token, the token itself is only sent to the user.
+	TokenHash string `gorm:"uniqueIndex"`
+	UserID    string `gorm:"index"`
+	ExpiresAt time.Time
+	// UsedAt is set once a password has been reset with it, or with a newer one of the user.
+	UsedAt    *time.Time
+	CreatedAt time.Time
+
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
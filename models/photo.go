@@ -1,6 +1,22 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// visibility levels of a photo or an album, the owner can always see their own photos and albums.
+const (
+	// VisibilityPublic photos are visible to everyone and listed everywhere.
+	VisibilityPublic = "public"
+	// VisibilityUnlisted photos are visible to anyone with their link but never listed.
+	VisibilityUnlisted = "unlisted"
+	// VisibilityFollowers photos are only visible to the followers of the owner.
+	VisibilityFollowers = "followers"
+	// VisibilityPrivate photos are only visible to the owner.
+	VisibilityPrivate = "private"
+)
 
 type Photo struct {
 	ID        string `gorm:"primaryKey"`
@@ -10,7 +26,57 @@ type Photo struct {
 	UserID    string
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	IsPrivate bool
+	// Version is the row version, it grows on every change of the photo and guards updates against lost writes.
+	Version int `gorm:"default:1"`
+	// DeletedAt is set while the photo is in the trash.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// Visibility is one of the Visibility* levels.
+	Visibility string `gorm:"default:public;index"`
+	Width      int
+	Height     int
+	// Size is the size in bytes of the served file.
+	Size int64
+	// Checksum is the hex SHA-256 of the served file, files stored before it was recorded get it on their first serve.
+	Checksum string
+	// FileVersion is the version number of the current file, it starts at 1 and grows on every replace.
+	FileVersion int `gorm:"default:1"`
+	// LastFileVersion is the highest version number given so far, numbers of purged versions aren't reused.
+	LastFileVersion int `gorm:"default:1"`
+
+	// StripMetadata is the metadata stripping mode applied to the served file.
+	StripMetadata string
+	// OriginalPath points to the untouched upload, only set when the owner chose to keep it.
+	OriginalPath string
+
+	User     User
+	Variants []PhotoVariant `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Metadata *PhotoMetadata `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Tags     []Tag          `gorm:"many2many:photo_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Versions []PhotoVersion `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+type PhotoVariant struct {
+	PhotoID   string `gorm:"primaryKey"`
+	Name      string `gorm:"primaryKey"`
+	PhotoPath string
+	Width     int
+	Height    int
+	Checksum  string
+}
 
-	User User
+type PhotoMetadata struct {
+	PhotoID      string `gorm:"primaryKey"`
+	CameraMake   string
+	CameraModel  string
+	LensModel    string
+	Software     string
+	FocalLength  *float64
+	Aperture     *float64
+	ExposureTime string
+	ISO          *int
+	Orientation  int
+	TakenAt      *time.Time
+	Latitude     *float64
+	Longitude    *float64
+	Altitude     *float64
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Session is a signed in device of a user. its refresh tokens form a family: every refresh replaces the token,
+// and using a replaced token again revokes the whole session.
+type Session struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"index"`
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	// LastUsedAt is the last time the session was refreshed.
+	LastUsedAt time.Time
+	// ExpiresAt is the expiry of the latest refresh token, the session can't be refreshed after it.
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+
+	User   User           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Tokens []RefreshToken `gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// RefreshToken can be exchanged once for a new access token and a new refresh token.
+type RefreshToken struct {
+	ID uint `gorm:"primaryKey"`
+	// TokenHash is the hash of the token, the token itself is only given to the client.
+	TokenHash string `gorm:"uniqueIndex"`
+	SessionID string `gorm:"index"`
+	ExpiresAt time.Time
+	// UsedAt is set once the token has been exchanged.
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
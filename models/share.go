@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ShareLink gives anyone holding its token access to a photo, or to an album and its photos, whatever their visibility.
+type ShareLink struct {
+	ID string `gorm:"primaryKey"`
+	// TokenHash is the hash of the token, the token itself is only given to the owner when the link is created.
+	TokenHash string `gorm:"uniqueIndex"`
+	UserID    string `gorm:"index"`
+	// either PhotoID or AlbumID is set.
+	PhotoID *string `gorm:"index"`
+	AlbumID *string `gorm:"index"`
+	// PasswordHash is empty when the link doesn't require a password.
+	PasswordHash string
+	ExpiresAt    *time.Time
+	// MaxViews is the number of views allowed, 0 means unlimited. a view is counted once per visit, see ShareLinkUse.
+	MaxViews  int
+	Views     int
+	RevokedAt *time.Time
+	CreatedAt time.Time
+
+	User  User           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Photo *Photo         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Album *Album         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Uses  []ShareLinkUse `gorm:"foreignKey:ShareLinkID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// ShareLinkUse records a request let through by a share link.
+type ShareLinkUse struct {
+	ID          uint   `gorm:"primaryKey"`
+	ShareLinkID string `gorm:"index"`
+	// PhotoID is the photo that was viewed, empty when only the album was.
+	PhotoID   string
+	IP        string
+	UserAgent string
+	// Counted is set on the use that counted a view of the link, the first one of a visit.
+	Counted   bool
+	CreatedAt time.Time
+}
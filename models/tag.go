@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Tag is a normalized lowercase slug, see helpers.NormalizeTag.
+type Tag struct {
+	Name      string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// TagCount is a tag along with the number of photos using it.
+type TagCount struct {
+	Name  string
+	Count int64
+}
@@ -10,4 +10,18 @@ type User struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Photos    []Photo `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Albums    []Album `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	// Version is the row version, it grows on every change of the user and guards updates against lost writes.
+	Version int `gorm:"default:1"`
+
+	// EmailVerifiedAt is set once the user has followed the link of the verification email, it's reset when they
+	// change their email.
+	EmailVerifiedAt *time.Time
+	// VerificationSentAt is when the last verification email was sent, it throttles resending it.
+	VerificationSentAt *time.Time
+
+	// default upload settings, can be overridden per upload.
+	StripMetadata string `gorm:"default:gps"`
+	KeepOriginal  bool
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PhotoVersion is a previous file of a photo, kept when the file is replaced so it can be restored later.
+// the variants and metadata of the file are kept along with it.
+type PhotoVersion struct {
+	PhotoID       string `gorm:"primaryKey"`
+	Version       int    `gorm:"primaryKey;autoIncrement:false"`
+	PhotoPath     string
+	OriginalPath  string
+	StripMetadata string
+	Width         int
+	Height        int
+	Size          int64
+	Checksum      string
+	Variants      []PhotoVariant `gorm:"serializer:json"`
+	Metadata      *PhotoMetadata `gorm:"serializer:json"`
+	// CreatedAt is the time the file was replaced.
+	CreatedAt time.Time
+}
+
+// Paths returns every stored file of the version.
+func (v PhotoVersion) Paths() []string {
+	paths := []string{v.PhotoPath, v.OriginalPath}
+	for _, variant := range v.Variants {
+		paths = append(paths, variant.PhotoPath)
+	}
+
+	return paths
+}
@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"photo-app/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AlbumRepository interface {
+	Create(context.Context, models.Album) (string, error)
+	FindByID(context.Context, string) (models.Album, error)
+	FindSharedByID(context.Context, string) (models.Album, error)
+	FindByUserID(context.Context, string) ([]models.Album, error)
+	Update(context.Context, models.Album, map[string]any) error
+	Delete(context.Context, models.Album) error
+	FindPhotos(context.Context, string) ([]models.AlbumPhoto, error)
+	FindSharedPhotos(context.Context, string) ([]models.AlbumPhoto, error)
+	AddPhoto(context.Context, string, string, int) error
+	RemovePhoto(context.Context, string, string) error
+	ReorderPhotos(context.Context, string, []string) error
+}
+
+type albumRepository struct {
+	db *gorm.DB
+}
+
+func NewAlbumRepository(db *gorm.DB) AlbumRepository {
+	return &albumRepository{db}
+}
+
+func (repo *albumRepository) Create(ctx context.Context, data models.Album) (string, error) {
+	err := repo.db.WithContext(ctx).Omit(clause.Associations).Create(&data).Error
+	if err != nil {
+		return data.ID, err
+	}
+
+	return data.ID, nil
+}
+
+func (repo *albumRepository) FindByID(ctx context.Context, id string) (models.Album, error) {
+	var album models.Album
+
+	err := repo.db.WithContext(ctx).Preload("User").Preload("CoverPhoto", repo.visibleCover(ctx)).Preload("CoverPhoto.Variants").
+		Where(visibleAlbums(repo.db, ctx.Value("id"), false)).First(&album, "id = ?", id).Error
+	if err != nil {
+		return album, err
+	}
+
+	return album, nil
+}
+
+// FindSharedByID finds an album whatever its visibility, the caller must have checked the share link of the request.
+func (repo *albumRepository) FindSharedByID(ctx context.Context, id string) (models.Album, error) {
+	var album models.Album
+
+	err := repo.db.WithContext(ctx).Preload("User").Preload("CoverPhoto", repo.visibleCover(ctx)).Preload("CoverPhoto.Variants").First(&album, "id = ?", id).Error
+	if err != nil {
+		return album, err
+	}
+
+	return album, nil
+}
+
+func (repo *albumRepository) FindByUserID(ctx context.Context, userID string) ([]models.Album, error) {
+	var albums []models.Album
+
+	err := repo.db.WithContext(ctx).Preload("CoverPhoto", repo.visibleCover(ctx)).Preload("CoverPhoto.Variants").Order("created_at DESC").
+		Where(visibleAlbums(repo.db, ctx.Value("id"), true)).Find(&albums, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return albums, nil
+}
+
+func (repo *albumRepository) Update(ctx context.Context, data models.Album, toUpdate map[string]any) error {
+	err := repo.db.WithContext(ctx).Model(&data).Omit(clause.Associations).Updates(toUpdate).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *albumRepository) Delete(ctx context.Context, data models.Album) error {
+	err := repo.db.WithContext(ctx).Delete(&data).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindPhotos returns the photos of an album ordered by their position, leaving out the ones the current user can't view.
+func (repo *albumRepository) FindPhotos(ctx context.Context, albumID string) ([]models.AlbumPhoto, error) {
+	var photos []models.AlbumPhoto
+
+	err := repo.db.WithContext(ctx).Preload("Photo").Preload("Photo.Variants").
+		Where("photo_id IN (?)", repo.db.Model(&models.Photo{}).Select("id").Where(visiblePhotos(repo.db, ctx.Value("id"), true))).
+		Order("position, created_at").Find(&photos, "album_id = ?", albumID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// FindSharedPhotos returns every photo of a shared album ordered by their position, whatever their visibility.
+func (repo *albumRepository) FindSharedPhotos(ctx context.Context, albumID string) ([]models.AlbumPhoto, error) {
+	var photos []models.AlbumPhoto
+
+	err := repo.db.WithContext(ctx).Preload("Photo").Preload("Photo.Variants").
+		Where("photo_id IN (?)", repo.db.Model(&models.Photo{}).Select("id")).
+		Order("position, created_at").Find(&photos, "album_id = ?", albumID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// AddPhoto inserts a photo into an album at the given position, moving the following photos one place down.
+// a negative or out of range position appends the photo at the end of the album.
+func (repo *albumRepository) AddPhoto(ctx context.Context, albumID, photoID string, position int) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the album row is locked so concurrent changes can't end up with the same positions.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Album{}, "id = ?", albumID).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&models.AlbumPhoto{}).Where("album_id = ?", albumID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if position < 0 || position > int(count) {
+			position = int(count)
+		}
+
+		err = tx.Model(&models.AlbumPhoto{}).Where("album_id = ? AND position >= ?", albumID, position).Update("position", gorm.Expr("position + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).Create(&models.AlbumPhoto{
+			AlbumID:  albumID,
+			PhotoID:  photoID,
+			Position: position,
+		}).Error
+	})
+}
+
+// RemovePhoto removes a photo from an album and closes the gap it leaves behind.
+func (repo *albumRepository) RemovePhoto(ctx context.Context, albumID, photoID string) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Album{}, "id = ?", albumID).Error
+		if err != nil {
+			return err
+		}
+
+		var albumPhoto models.AlbumPhoto
+		err = tx.First(&albumPhoto, "album_id = ? AND photo_id = ?", albumID, photoID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&albumPhoto).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.AlbumPhoto{}).Where("album_id = ? AND position > ?", albumID, albumPhoto.Position).Update("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+// ReorderPhotos sets the position of every photo in an album to its index in photoIDs.
+// photoIDs must contain every photo of the album exactly once, otherwise gorm.ErrRecordNotFound is returned.
+func (repo *albumRepository) ReorderPhotos(ctx context.Context, albumID string, photoIDs []string) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Album{}, "id = ?", albumID).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&models.AlbumPhoto{}).Where("album_id = ?", albumID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if int(count) != len(photoIDs) {
+			return gorm.ErrRecordNotFound
+		}
+
+		for i, photoID := range photoIDs {
+			res := tx.Model(&models.AlbumPhoto{}).Where("album_id = ? AND photo_id = ?", albumID, photoID).Update("position", i)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+
+		return nil
+	})
+}
+
+// visibleCover only preloads the cover photo when the current user can see it, the album itself may be visible to more people.
+func (repo *albumRepository) visibleCover(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(visiblePhotos(repo.db, ctx.Value("id"), true))
+	}
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"photo-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FileRepository interface {
+	Create(context.Context, []models.FileDeletion) ([]models.FileDeletion, error)
+	FindAll(context.Context) ([]models.FileDeletion, error)
+	FindDue(context.Context, time.Time, int) ([]models.FileDeletion, error)
+	Delete(context.Context, models.FileDeletion) error
+	Postpone(context.Context, models.FileDeletion, string, time.Time) error
+	FindPhotoFiles(context.Context, string, int) ([]models.Photo, error)
+}
+
+type fileRepository struct {
+	db *gorm.DB
+}
+
+func NewFileRepository(db *gorm.DB) FileRepository {
+	return &fileRepository{db}
+}
+
+func (repo *fileRepository) Create(ctx context.Context, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	if len(deletions) == 0 {
+		return deletions, nil
+	}
+
+	err := repo.db.WithContext(ctx).Create(&deletions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+func (repo *fileRepository) FindAll(ctx context.Context) ([]models.FileDeletion, error) {
+	var deletions []models.FileDeletion
+
+	err := repo.db.WithContext(ctx).Find(&deletions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// FindDue returns at most limit deletions that can be processed at t, oldest first.
+func (repo *fileRepository) FindDue(ctx context.Context, t time.Time, limit int) ([]models.FileDeletion, error) {
+	var deletions []models.FileDeletion
+
+	err := repo.db.WithContext(ctx).Order("not_before, id").Limit(limit).Find(&deletions, "not_before <= ?", t).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+func (repo *fileRepository) Delete(ctx context.Context, data models.FileDeletion) error {
+	err := repo.db.WithContext(ctx).Delete(&data).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Postpone records a failed attempt of a deletion and schedules the next one.
+func (repo *fileRepository) Postpone(ctx context.Context, data models.FileDeletion, reason string, next time.Time) error {
+	err := repo.db.WithContext(ctx).Model(&data).Updates(map[string]any{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+		"not_before": next,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindPhotoFiles returns at most limit photos with an ID greater than afterID, trashed ones included,
+// along with everything that references a file: variants and previous versions.
+func (repo *fileRepository) FindPhotoFiles(ctx context.Context, afterID string, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := repo.db.WithContext(ctx).Unscoped().Preload("Variants").Preload("Versions").Order("id").Limit(limit).Find(&photos, "id > ?", afterID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// releaseGuards deletes the guards of an upload within the transaction that commits it.
+func releaseGuards(tx *gorm.DB, guards []models.FileDeletion) error {
+	if len(guards) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(guards))
+	for i, guard := range guards {
+		ids[i] = guard.ID
+	}
+
+	return tx.Delete(&models.FileDeletion{}, ids).Error
+}
+
+// scheduleDeletions records deletions within the transaction that stops referencing the files.
+func scheduleDeletions(tx *gorm.DB, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	if len(deletions) == 0 {
+		return deletions, nil
+	}
+
+	err := tx.Create(&deletions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
@@ -2,18 +2,104 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"photo-app/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PhotoRepository interface {
-	Create(context.Context, models.Photo) (string, error)
-	FindAll(context.Context) ([]models.Photo, error)
+	Create(context.Context, models.Photo, []models.FileDeletion) (string, error)
+	FindAll(context.Context, PageQuery) ([]models.Photo, error)
 	FindByID(context.Context, string) (models.Photo, error)
-	FindByUserID(context.Context, string) ([]models.Photo, error)
-	Update(context.Context, models.Photo, map[string]any) error
+	FindSharedByID(context.Context, string) (models.Photo, error)
+	FindFilesByID(context.Context, string) (models.Photo, error)
+	FindByUserID(context.Context, string, PageQuery) ([]models.Photo, error)
+	Update(context.Context, models.Photo, map[string]any, []models.Tag) error
 	Delete(context.Context, models.Photo) error
+	FindMetadataByPhotoID(context.Context, string) (models.PhotoMetadata, error)
+	Search(context.Context, SearchQuery) ([]models.Photo, error)
+	FindVersions(context.Context, string) ([]models.PhotoVersion, error)
+	ReplaceFile(context.Context, models.Photo, []models.FileDeletion) (int, error)
+	RestoreVersion(context.Context, string, int) error
+	DeleteVersions(context.Context, string, []int, []models.FileDeletion) ([]models.FileDeletion, error)
+	FindTrash(context.Context, string) ([]models.Photo, error)
+	FindTrashedByID(context.Context, string) (models.Photo, error)
+	FindTrashedBefore(context.Context, time.Time, int) ([]models.Photo, error)
+	Restore(context.Context, models.Photo) error
+	Purge(context.Context, models.Photo, []models.FileDeletion) ([]models.FileDeletion, error)
+	FindUnstripped(context.Context, string, int) ([]models.Photo, error)
+	StripFile(context.Context, models.Photo, models.Photo, []models.FileDeletion, []models.FileDeletion) ([]models.FileDeletion, error)
+	SetChecksum(context.Context, string, int, string, string, string) error
+}
+
+// SearchQuery is a full-text search over photo titles and captions. photos tagged with one of Tags match as well.
+type SearchQuery struct {
+	Text   string
+	Tags   []string
+	Limit  int
+	Offset int
+}
+
+// PageQuery selects a page of rows ordered by (created_at, id).
+// when CursorID is set, only rows strictly after the cursor (in the requested order) are returned.
+type PageQuery struct {
+	Limit           int
+	Desc            bool
+	CursorCreatedAt time.Time
+	CursorID        string
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+}
+
+func (q PageQuery) scope(db *gorm.DB) *gorm.DB {
+	if !q.CreatedAfter.IsZero() {
+		db = db.Where("created_at > ?", q.CreatedAfter)
+	}
+	if !q.CreatedBefore.IsZero() {
+		db = db.Where("created_at < ?", q.CreatedBefore)
+	}
+
+	op, order := ">", "created_at, id"
+	if q.Desc {
+		op, order = "<", "created_at DESC, id DESC"
+	}
+	if q.CursorID != "" {
+		db = db.Where(fmt.Sprintf("(created_at, id) %s (?, ?)", op), q.CursorCreatedAt, q.CursorID)
+	}
+
+	return db.Order(order).Limit(q.Limit)
+}
+
+// visiblePhotos is the visibility policy of photos, every query returning photos to a viewer must go through it.
+func visiblePhotos(db *gorm.DB, viewerID any, listed bool) *gorm.DB {
+	return visibleRows(db, "photos", viewerID, listed)
+}
+
+// visibleAlbums is the visibility policy of albums, the same as the one of photos.
+func visibleAlbums(db *gorm.DB, viewerID any, listed bool) *gorm.DB {
+	return visibleRows(db, "albums", viewerID, listed)
+}
+
+// visibleRows returns a condition on a table with visibility and user_id columns matching the rows viewerID can
+// see, a nil or empty viewerID being an anonymous viewer. unlisted rows only match when listed is false, that is
+// when a row is looked up by its ID.
+func visibleRows(db *gorm.DB, table string, viewerID any, listed bool) *gorm.DB {
+	visibilities := []string{models.VisibilityPublic}
+	if !listed {
+		visibilities = append(visibilities, models.VisibilityUnlisted)
+	}
+
+	cond := db.Where(table+".visibility IN ?", visibilities)
+	if viewerID == nil || viewerID == "" {
+		return cond
+	}
+
+	followees := db.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", viewerID)
+	return cond.Or(table+".user_id = ?", viewerID).
+		Or(table+".visibility = ? AND "+table+".user_id IN (?)", models.VisibilityFollowers, followees)
 }
 
 type photoRepository struct {
@@ -24,8 +110,16 @@ func NewPhotoRepository(db *gorm.DB) PhotoRepository {
 	return &photoRepository{db}
 }
 
-func (repo *photoRepository) Create(ctx context.Context, data models.Photo) (string, error) {
-	err := repo.db.WithContext(ctx).Create(&data).Error
+// Create inserts the photo and releases the guards of its uploaded files in the same transaction.
+func (repo *photoRepository) Create(ctx context.Context, data models.Photo, guards []models.FileDeletion) (string, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&data).Error
+		if err != nil {
+			return err
+		}
+
+		return releaseGuards(tx, guards)
+	})
 	if err != nil {
 		return data.ID, err
 	}
@@ -33,9 +127,10 @@ func (repo *photoRepository) Create(ctx context.Context, data models.Photo) (str
 	return data.ID, nil
 }
 
-func (repo *photoRepository) FindAll(ctx context.Context) ([]models.Photo, error) {
+func (repo *photoRepository) FindAll(ctx context.Context, q PageQuery) ([]models.Photo, error) {
 	var photos []models.Photo
-	err := repo.db.WithContext(ctx).Preload("User").Find(&photos, "NOT is_private").Error
+	err := repo.db.WithContext(ctx).Scopes(q.scope).Preload("User").Preload("Variants").Preload("Tags").
+		Where(visiblePhotos(repo.db, nil, true)).Find(&photos).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,10 +138,11 @@ func (repo *photoRepository) FindAll(ctx context.Context) ([]models.Photo, error
 	return photos, nil
 }
 
-func (repo *photoRepository) FindByUserID(ctx context.Context, userID string) ([]models.Photo, error) {
+func (repo *photoRepository) FindByUserID(ctx context.Context, userID string, q PageQuery) ([]models.Photo, error) {
 	var photos []models.Photo
 
-	err := repo.db.WithContext(ctx).Find(&photos, "(user_id = ? AND NOT is_private) OR user_id = ?", userID, ctx.Value("id")).Error
+	err := repo.db.WithContext(ctx).Scopes(q.scope).Preload("Variants").Preload("Tags").
+		Where(visiblePhotos(repo.db, ctx.Value("id"), true)).Find(&photos, "user_id = ?", userID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +153,8 @@ func (repo *photoRepository) FindByUserID(ctx context.Context, userID string) ([
 func (repo *photoRepository) FindByID(ctx context.Context, id string) (models.Photo, error) {
 	var photo models.Photo
 
-	err := repo.db.WithContext(ctx).First(&photo, "id = ? AND (NOT is_private OR user_id = ?)", id, ctx.Value("id")).Error
+	err := repo.db.WithContext(ctx).Preload("User").Preload("Variants").Preload("Tags").
+		Where(visiblePhotos(repo.db, ctx.Value("id"), false)).First(&photo, "id = ?", id).Error
 	if err != nil {
 		return photo, err
 	}
@@ -65,13 +162,57 @@ func (repo *photoRepository) FindByID(ctx context.Context, id string) (models.Ph
 	return photo, nil
 }
 
-func (repo *photoRepository) Update(ctx context.Context, data models.Photo, toUpdate map[string]any) error {
-	err := repo.db.WithContext(ctx).Model(&data).Updates(toUpdate).Error
+// FindSharedByID finds a photo whatever its visibility, the caller must have checked the share link of the request.
+func (repo *photoRepository) FindSharedByID(ctx context.Context, id string) (models.Photo, error) {
+	var photo models.Photo
+
+	err := repo.db.WithContext(ctx).Preload("User").Preload("Variants").Preload("Tags").First(&photo, "id = ?", id).Error
 	if err != nil {
-		return err
+		return photo, err
 	}
 
-	return nil
+	return photo, nil
+}
+
+// FindFilesByID finds a photo that isn't in the trash along with every file it references. it doesn't check
+// whether the current user can see the photo.
+func (repo *photoRepository) FindFilesByID(ctx context.Context, id string) (models.Photo, error) {
+	var photo models.Photo
+
+	err := repo.db.WithContext(ctx).Preload("Variants").Preload("Versions").First(&photo, "id = ?", id).Error
+	if err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// Update updates the given columns and tags of a photo if its version is still data.Version, the version is
+// increased along. it returns gorm.ErrRecordNotFound when the photo has been changed in the meantime.
+func (repo *photoRepository) Update(ctx context.Context, data models.Photo, toUpdate map[string]any, tags []models.Tag) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		columns := map[string]any{"version": gorm.Expr("version + 1")}
+		for column, value := range toUpdate {
+			columns[column] = value
+		}
+
+		res := tx.Model(&models.Photo{}).Where("id = ? AND version = ?", data.ID, data.Version).Updates(columns)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if tags != nil {
+			err := tx.Model(&data).Association("Tags").Replace(tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func (repo *photoRepository) Delete(ctx context.Context, data models.Photo) error {
@@ -82,3 +223,345 @@ func (repo *photoRepository) Delete(ctx context.Context, data models.Photo) erro
 
 	return nil
 }
+
+func (repo *photoRepository) FindMetadataByPhotoID(ctx context.Context, photoID string) (models.PhotoMetadata, error) {
+	var metadata models.PhotoMetadata
+
+	err := repo.db.WithContext(ctx).First(&metadata, "photo_id = ?", photoID).Error
+	if err != nil {
+		return metadata, err
+	}
+
+	return metadata, nil
+}
+
+// Search returns photos matching the query, best match first. the current user's own photos are included.
+func (repo *photoRepository) Search(ctx context.Context, q SearchQuery) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	tagged := repo.db.Table("photo_tags").Select("photo_id").Where("tag_name IN ?", q.Tags)
+	rank := clause.Expr{
+		SQL:  "ts_rank(search_vector, websearch_to_tsquery('simple', ?)) + CASE WHEN id IN (?) THEN 1 ELSE 0 END DESC, created_at DESC, id DESC",
+		Vars: []any{q.Text, tagged},
+	}
+
+	err := repo.db.WithContext(ctx).Preload("User").Preload("Variants").Preload("Tags").
+		Where("search_vector @@ websearch_to_tsquery('simple', ?) OR id IN (?)", q.Text, tagged).
+		Where(visiblePhotos(repo.db, ctx.Value("id"), true)).
+		Clauses(clause.OrderBy{Expression: rank}).
+		Limit(q.Limit).Offset(q.Offset).
+		Find(&photos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+func (repo *photoRepository) FindVersions(ctx context.Context, photoID string) ([]models.PhotoVersion, error) {
+	var versions []models.PhotoVersion
+
+	err := repo.db.WithContext(ctx).Order("version DESC").Find(&versions, "photo_id = ?", photoID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// ReplaceFile keeps the current file of the photo as a version and replaces it with the file of data,
+// along with its variants and metadata, releasing the guards of the new files. it returns the version number of the new file.
+func (repo *photoRepository) ReplaceFile(ctx context.Context, data models.Photo, guards []models.FileDeletion) (int, error) {
+	var version int
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := archiveFile(tx, data.ID)
+		if err != nil {
+			return err
+		}
+
+		version = max(current.LastFileVersion, current.FileVersion) + 1
+		err = tx.Model(&models.Photo{ID: current.ID}).Update("last_file_version", version).Error
+		if err != nil {
+			return err
+		}
+
+		data.FileVersion = version
+		err = applyFile(tx, data)
+		if err != nil {
+			return err
+		}
+
+		return releaseGuards(tx, guards)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// RestoreVersion makes a previous version the current file of the photo, the replaced file is kept as a version.
+func (repo *photoRepository) RestoreVersion(ctx context.Context, photoID string, version int) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var restored models.PhotoVersion
+		err := tx.First(&restored, "photo_id = ? AND version = ?", photoID, version).Error
+		if err != nil {
+			return err
+		}
+
+		_, err = archiveFile(tx, photoID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&restored).Error
+		if err != nil {
+			return err
+		}
+
+		return applyFile(tx, models.Photo{
+			ID:            photoID,
+			PhotoPath:     restored.PhotoPath,
+			OriginalPath:  restored.OriginalPath,
+			StripMetadata: restored.StripMetadata,
+			Width:         restored.Width,
+			Height:        restored.Height,
+			Size:          restored.Size,
+			Checksum:      restored.Checksum,
+			FileVersion:   restored.Version,
+			Variants:      restored.Variants,
+			Metadata:      restored.Metadata,
+		})
+	})
+}
+
+// DeleteVersions deletes versions of a photo and schedules the deletion of their files in the same transaction.
+func (repo *photoRepository) DeleteVersions(ctx context.Context, photoID string, versions []int, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("photo_id = ? AND version IN ?", photoID, versions).Delete(&models.PhotoVersion{}).Error
+		if err != nil {
+			return err
+		}
+
+		deletions, err = scheduleDeletions(tx, deletions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// archiveFile locks the photo and copies its current file into a new version. it returns the photo as it was.
+func archiveFile(tx *gorm.DB, photoID string) (models.Photo, error) {
+	var current models.Photo
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Variants").Preload("Metadata").First(&current, "id = ?", photoID).Error
+	if err != nil {
+		return current, err
+	}
+
+	return current, tx.Create(&models.PhotoVersion{
+		PhotoID:       current.ID,
+		Version:       current.FileVersion,
+		PhotoPath:     current.PhotoPath,
+		OriginalPath:  current.OriginalPath,
+		StripMetadata: current.StripMetadata,
+		Width:         current.Width,
+		Height:        current.Height,
+		Size:          current.Size,
+		Checksum:      current.Checksum,
+		Variants:      current.Variants,
+		Metadata:      current.Metadata,
+	}).Error
+}
+
+// applyFile sets the file related columns, variants and metadata of a photo to the ones of data.
+func applyFile(tx *gorm.DB, data models.Photo) error {
+	err := tx.Where("photo_id = ?", data.ID).Delete(&models.PhotoVariant{}).Error
+	if err != nil {
+		return err
+	}
+	if len(data.Variants) > 0 {
+		for i := range data.Variants {
+			data.Variants[i].PhotoID = data.ID
+		}
+		err = tx.Create(&data.Variants).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Where("photo_id = ?", data.ID).Delete(&models.PhotoMetadata{}).Error
+	if err != nil {
+		return err
+	}
+	if data.Metadata != nil {
+		data.Metadata.PhotoID = data.ID
+		err = tx.Create(data.Metadata).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Photo{ID: data.ID}).Updates(map[string]any{
+		"photo_path":     data.PhotoPath,
+		"original_path":  data.OriginalPath,
+		"strip_metadata": data.StripMetadata,
+		"width":          data.Width,
+		"height":         data.Height,
+		"size":           data.Size,
+		"checksum":       data.Checksum,
+		"file_version":   data.FileVersion,
+		"version":        gorm.Expr("version + 1"),
+	}).Error
+}
+
+func (repo *photoRepository) FindTrash(ctx context.Context, userID string) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := repo.db.WithContext(ctx).Unscoped().Preload("Variants").Preload("Versions").Preload("Tags").Order("deleted_at DESC").Find(&photos, "user_id = ? AND deleted_at IS NOT NULL", userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+func (repo *photoRepository) FindTrashedByID(ctx context.Context, id string) (models.Photo, error) {
+	var photo models.Photo
+
+	err := repo.db.WithContext(ctx).Unscoped().Preload("Variants").Preload("Versions").First(&photo, "id = ? AND deleted_at IS NOT NULL", id).Error
+	if err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// FindTrashedBefore returns at most limit photos moved to the trash before t, along with every file related data.
+func (repo *photoRepository) FindTrashedBefore(ctx context.Context, t time.Time, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := repo.db.WithContext(ctx).Unscoped().Preload("Variants").Preload("Versions").Order("deleted_at").Limit(limit).Find(&photos, "deleted_at < ?", t).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+func (repo *photoRepository) Restore(ctx context.Context, data models.Photo) error {
+	err := repo.db.WithContext(ctx).Unscoped().Model(&models.Photo{ID: data.ID}).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Purge deletes the photo row for good and schedules the deletion of its files in the same transaction.
+// Delete only moves it to the trash.
+func (repo *photoRepository) Purge(ctx context.Context, data models.Photo, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Delete(&models.Photo{ID: data.ID}).Error
+		if err != nil {
+			return err
+		}
+
+		deletions, err = scheduleDeletions(tx, deletions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// FindUnstripped finds the photos, trashed ones included, whose file was stored before metadata stripping existed,
+// along with their owner. they're ordered by ID, starting after afterID.
+func (repo *photoRepository) FindUnstripped(ctx context.Context, afterID string, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := repo.db.WithContext(ctx).Unscoped().Preload("User").Order("id").Limit(limit).
+		Find(&photos, "id > ? AND (strip_metadata IS NULL OR strip_metadata = '')", afterID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// StripFile sets the stripped file of data on a photo found by FindUnstripped, releasing the guards of the new files
+// and scheduling the deletions of the old ones in the same transaction. it returns gorm.ErrRecordNotFound when the
+// file of the photo has changed since current was read.
+func (repo *photoRepository) StripFile(ctx context.Context, current, data models.Photo, guards, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&models.Photo{}).
+			Where("id = ? AND photo_path = ? AND (strip_metadata IS NULL OR strip_metadata = '')", current.ID, current.PhotoPath).
+			Updates(map[string]any{
+				"photo_path":        data.PhotoPath,
+				"original_path":     data.OriginalPath,
+				"strip_metadata":    data.StripMetadata,
+				"size":              data.Size,
+				"checksum":          data.Checksum,
+				"file_version":      data.FileVersion,
+				"last_file_version": data.LastFileVersion,
+				"version":           gorm.Expr("version + 1"),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := releaseGuards(tx, guards)
+		if err != nil {
+			return err
+		}
+
+		deletions, err = scheduleDeletions(tx, deletions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// SetChecksum records the checksum of a file stored before checksums were. the file is found by the version it
+// belongs to (0 for the current file), its variant name (empty for the photo file itself) and its path, so a file
+// replaced in the meantime is left alone. it doesn't change the version of the photo, the file itself didn't change.
+func (repo *photoRepository) SetChecksum(ctx context.Context, photoID string, version int, variant, filePath, checksum string) error {
+	db := repo.db.WithContext(ctx)
+
+	switch {
+	case version == 0 && variant == "":
+		return db.Model(&models.Photo{}).Where("id = ? AND photo_path = ?", photoID, filePath).UpdateColumn("checksum", checksum).Error
+	case version == 0:
+		return db.Model(&models.PhotoVariant{}).Where("photo_id = ? AND name = ? AND photo_path = ?", photoID, variant, filePath).UpdateColumn("checksum", checksum).Error
+	case variant == "":
+		return db.Model(&models.PhotoVersion{}).Where("photo_id = ? AND version = ? AND photo_path = ?", photoID, version, filePath).UpdateColumn("checksum", checksum).Error
+	}
+
+	// the variants of a previous version are serialized in its row.
+	return db.Transaction(func(tx *gorm.DB) error {
+		var v models.PhotoVersion
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&v, "photo_id = ? AND version = ?", photoID, version).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range v.Variants {
+			if v.Variants[i].Name == variant && v.Variants[i].PhotoPath == filePath {
+				v.Variants[i].Checksum = checksum
+			}
+		}
+
+		return tx.Model(&v).Select("variants").UpdateColumns(&v).Error
+	})
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"photo-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(context.Context, models.Session, models.RefreshToken) error
+	FindToken(context.Context, string) (models.RefreshToken, error)
+	FindByID(context.Context, string) (models.Session, error)
+	FindByUserID(context.Context, string) ([]models.Session, error)
+	Rotate(context.Context, models.RefreshToken, models.RefreshToken) error
+	Revoke(context.Context, string) error
+	RevokeOthers(context.Context, string, string) error
+	IsActive(context.Context, string) (bool, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db}
+}
+
+// Create creates a session along with its first refresh token.
+func (repo *sessionRepository) Create(ctx context.Context, data models.Session, token models.RefreshToken) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("User", "Tokens").Create(&data).Error
+		if err != nil {
+			return err
+		}
+
+		token.SessionID = data.ID
+		return tx.Create(&token).Error
+	})
+}
+
+func (repo *sessionRepository) FindToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	err := repo.db.WithContext(ctx).First(&token, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (repo *sessionRepository) FindByID(ctx context.Context, id string) (models.Session, error) {
+	var session models.Session
+
+	err := repo.db.WithContext(ctx).First(&session, "id = ?", id).Error
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// FindByUserID returns the sessions of a user that can still be used, the most recently used first.
+func (repo *sessionRepository) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	var sessions []models.Session
+
+	err := repo.db.WithContext(ctx).Order("last_used_at DESC").
+		Find(&sessions, "user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Rotate exchanges a refresh token for next in the same session. it returns gorm.ErrRecordNotFound when the token
+// has already been exchanged or the session has been revoked in the meantime.
+func (repo *sessionRepository) Rotate(ctx context.Context, used models.RefreshToken, next models.RefreshToken) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// the token is marked with a single conditional update, so it can't be exchanged twice concurrently.
+		res := tx.Model(&models.RefreshToken{}).Where("id = ? AND used_at IS NULL", used.ID).Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		res = tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", used.SessionID).Updates(map[string]any{
+			"last_used_at": now,
+			"expires_at":   next.ExpiresAt,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		next.SessionID = used.SessionID
+		return tx.Create(&next).Error
+	})
+}
+
+// Revoke signs a session out for good, its access tokens are rejected from then on and its refresh tokens can't be
+// exchanged anymore. revoking a revoked session keeps its first revocation time.
+func (repo *sessionRepository) Revoke(ctx context.Context, id string) error {
+	err := repo.db.WithContext(ctx).Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeOthers signs out every session of a user but the one with the given ID.
+func (repo *sessionRepository) RevokeOthers(ctx context.Context, userID, keepID string) error {
+	err := repo.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsActive reports whether a session exists and hasn't been revoked.
+func (repo *sessionRepository) IsActive(ctx context.Context, id string) (bool, error) {
+	var count int64
+
+	err := repo.db.WithContext(ctx).Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
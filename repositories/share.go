@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"photo-app/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShareRepository interface {
+	Create(context.Context, models.ShareLink) (string, error)
+	FindByID(context.Context, string) (models.ShareLink, error)
+	FindByToken(context.Context, string) (models.ShareLink, error)
+	FindByUserID(context.Context, string) ([]models.ShareLink, error)
+	Revoke(context.Context, models.ShareLink) error
+	Use(context.Context, models.ShareLink, models.ShareLinkUse, bool, time.Time) error
+	FindUses(context.Context, string) ([]models.ShareLinkUse, error)
+	HasAlbumPhoto(context.Context, string, string) (bool, error)
+}
+
+type shareRepository struct {
+	db *gorm.DB
+}
+
+func NewShareRepository(db *gorm.DB) ShareRepository {
+	return &shareRepository{db}
+}
+
+func (repo *shareRepository) Create(ctx context.Context, data models.ShareLink) (string, error) {
+	err := repo.db.WithContext(ctx).Omit("User", "Photo", "Album", "Uses").Create(&data).Error
+	if err != nil {
+		return data.ID, err
+	}
+
+	return data.ID, nil
+}
+
+func (repo *shareRepository) FindByID(ctx context.Context, id string) (models.ShareLink, error) {
+	var link models.ShareLink
+
+	err := repo.db.WithContext(ctx).First(&link, "id = ?", id).Error
+	if err != nil {
+		return link, err
+	}
+
+	return link, nil
+}
+
+func (repo *shareRepository) FindByToken(ctx context.Context, tokenHash string) (models.ShareLink, error) {
+	var link models.ShareLink
+
+	err := repo.db.WithContext(ctx).First(&link, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		return link, err
+	}
+
+	return link, nil
+}
+
+func (repo *shareRepository) FindByUserID(ctx context.Context, userID string) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+
+	err := repo.db.WithContext(ctx).Order("created_at DESC").Find(&links, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// Revoke disables a link for good, revoking a revoked link keeps its first revocation time.
+func (repo *shareRepository) Revoke(ctx context.Context, data models.ShareLink) error {
+	err := repo.db.WithContext(ctx).Model(&data).Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Use records a use of the link, as long as the link is still usable at that time. a metered use counts a view,
+// unless the same IP already had one counted after visitSince: the requests of a single visit count once.
+// it returns gorm.ErrRecordNotFound when the link has been revoked, has expired or has no views left for a new visit.
+func (repo *shareRepository) Use(ctx context.Context, data models.ShareLink, use models.ShareLinkUse, metered bool, visitSince time.Time) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the link row is locked so concurrent uses can't go over MaxViews, nor both count the same visit.
+		var link models.ShareLink
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("revoked_at IS NULL").
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			First(&link, "id = ?", data.ID).Error
+		if err != nil {
+			return err
+		}
+
+		var visits int64
+		err = tx.Model(&models.ShareLinkUse{}).
+			Where("share_link_id = ? AND ip = ? AND counted AND created_at > ?", link.ID, use.IP, visitSince).
+			Count(&visits).Error
+		if err != nil {
+			return err
+		}
+
+		if visits == 0 {
+			if link.MaxViews > 0 && link.Views >= link.MaxViews {
+				return gorm.ErrRecordNotFound
+			}
+			if metered {
+				err = tx.Model(&link).Update("views", gorm.Expr("views + 1")).Error
+				if err != nil {
+					return err
+				}
+				use.Counted = true
+			}
+		}
+
+		use.ShareLinkID = link.ID
+		return tx.Create(&use).Error
+	})
+}
+
+func (repo *shareRepository) FindUses(ctx context.Context, linkID string) ([]models.ShareLinkUse, error) {
+	var uses []models.ShareLinkUse
+
+	err := repo.db.WithContext(ctx).Order("created_at DESC, id DESC").Find(&uses, "share_link_id = ?", linkID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return uses, nil
+}
+
+func (repo *shareRepository) HasAlbumPhoto(ctx context.Context, albumID, photoID string) (bool, error) {
+	var count int64
+
+	err := repo.db.WithContext(ctx).Model(&models.AlbumPhoto{}).Where("album_id = ? AND photo_id = ?", albumID, photoID).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"photo-app/models"
+
+	"gorm.io/gorm"
+)
+
+type TagRepository interface {
+	FindAll(context.Context, int) ([]models.TagCount, error)
+	FindPhotos(context.Context, string, PageQuery) ([]models.Photo, error)
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db}
+}
+
+// FindAll returns the most used tags, only public photos are counted.
+func (repo *tagRepository) FindAll(ctx context.Context, limit int) ([]models.TagCount, error) {
+	var tags []models.TagCount
+
+	err := repo.db.WithContext(ctx).Table("photo_tags").
+		Select("photo_tags.tag_name AS name, COUNT(*) AS count").
+		Joins("JOIN photos ON photos.id = photo_tags.photo_id").
+		Where(visiblePhotos(repo.db, nil, true)).Where("photos.deleted_at IS NULL").
+		Group("photo_tags.tag_name").
+		Order("count DESC, name").
+		Limit(limit).
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (repo *tagRepository) FindPhotos(ctx context.Context, tag string, q PageQuery) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	tagged := repo.db.Table("photo_tags").Select("photo_id").Where("tag_name = ?", tag)
+	err := repo.db.WithContext(ctx).Scopes(q.scope).Preload("User").Preload("Variants").Preload("Tags").
+		Where("id IN (?)", tagged).
+		Where(visiblePhotos(repo.db, ctx.Value("id"), true)).Find(&photos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
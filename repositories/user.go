@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 	"photo-app/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
@@ -13,7 +15,15 @@ type UserRepository interface {
 	FindByUsername(context.Context, string) (models.User, error)
 	FindByID(context.Context, string) (models.User, error)
 	Update(context.Context, models.User) error
-	Delete(context.Context, models.User) error
+	UpdateSettings(context.Context, models.User, map[string]any) error
+	MarkVerified(context.Context, string, string, time.Time) error
+	MarkVerificationSent(context.Context, string, time.Time, time.Time) error
+	CreatePasswordReset(context.Context, models.PasswordReset, time.Time) error
+	FindPasswordReset(context.Context, string) (models.PasswordReset, error)
+	ResetPassword(context.Context, models.PasswordReset, string) error
+	Delete(context.Context, models.User, []models.FileDeletion) ([]models.FileDeletion, error)
+	Follow(context.Context, string, string) error
+	Unfollow(context.Context, string, string) error
 }
 
 type userRepository struct {
@@ -66,8 +76,37 @@ func (repo *userRepository) FindByID(ctx context.Context, id string) (models.Use
 	return user, nil
 }
 
+// Update saves the user if its version is still data.Version, the version is increased along. it returns
+// gorm.ErrRecordNotFound when the user has been changed in the meantime.
 func (repo *userRepository) Update(ctx context.Context, data models.User) error {
-	err := repo.db.WithContext(ctx).Updates(&data).Error
+	version := data.Version
+	data.Version++
+
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("version = ?", version).Updates(&data)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Updates skips nil fields, so the verification reset along with a change of email is written on its own.
+		if data.EmailVerifiedAt == nil {
+			return tx.Model(&data).UpdateColumn("email_verified_at", nil).Error
+		}
+
+		return nil
+	})
+}
+
+func (repo *userRepository) UpdateSettings(ctx context.Context, data models.User, toUpdate map[string]any) error {
+	columns := map[string]any{"version": gorm.Expr("version + 1")}
+	for column, value := range toUpdate {
+		columns[column] = value
+	}
+
+	err := repo.db.WithContext(ctx).Model(&data).Updates(columns).Error
 	if err != nil {
 		return err
 	}
@@ -75,11 +114,149 @@ func (repo *userRepository) Update(ctx context.Context, data models.User) error
 	return nil
 }
 
-func (repo *userRepository) Delete(ctx context.Context, data models.User) error {
-	err := repo.db.WithContext(ctx).Delete(&data).Error
+// MarkVerified verifies the email of a user if it's still the given one. it returns gorm.ErrRecordNotFound when the
+// user has already been verified or has changed their email in the meantime.
+func (repo *userRepository) MarkVerified(ctx context.Context, id, email string, verifiedAt time.Time) error {
+	res := repo.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", id, email).
+		Updates(map[string]any{
+			"email_verified_at": verifiedAt,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// MarkVerificationSent records that a verification email is sent to an unverified user, unless one has already been
+// sent after notBefore. it returns gorm.ErrRecordNotFound when it's too early or the user is already verified, the
+// check and the update are a single statement so concurrent requests can't both send one.
+func (repo *userRepository) MarkVerificationSent(ctx context.Context, id string, sentAt, notBefore time.Time) error {
+	res := repo.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", id, notBefore).
+		Update("verification_sent_at", sentAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CreatePasswordReset creates a password reset, unless the user has been sent one after notBefore. it returns
+// gorm.ErrRecordNotFound when it's too early.
+func (repo *userRepository) CreatePasswordReset(ctx context.Context, data models.PasswordReset, notBefore time.Time) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the user row is locked so concurrent requests can't both see no recent reset and create one each.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, "id = ?", data.UserID).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&models.PasswordReset{}).Where("user_id = ? AND created_at > ?", data.UserID, notBefore).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Omit("User").Create(&data).Error
+	})
+}
+
+func (repo *userRepository) FindPasswordReset(ctx context.Context, tokenHash string) (models.PasswordReset, error) {
+	var reset models.PasswordReset
+
+	err := repo.db.WithContext(ctx).First(&reset, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		return reset, err
+	}
+
+	return reset, nil
+}
+
+// ResetPassword sets the password of the user of a password reset and signs out all of their sessions. the reset is
+// used up along with every other reset of the user. it returns gorm.ErrRecordNotFound when the reset has already been
+// used in the meantime.
+func (repo *userRepository) ResetPassword(ctx context.Context, reset models.PasswordReset, password string) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// the reset is marked with a single conditional update, so it can't be used twice concurrently.
+		res := tx.Model(&models.PasswordReset{}).Where("id = ? AND used_at IS NULL", reset.ID).Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := tx.Model(&models.PasswordReset{}).Where("user_id = ? AND used_at IS NULL", reset.UserID).Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.User{}).Where("id = ?", reset.UserID).Updates(map[string]any{
+			"password": password,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", reset.UserID).Update("revoked_at", now).Error
+	})
+}
+
+// Delete deletes the user, their photos and albums are deleted along by the database. the deletion of their files
+// is scheduled in the same transaction.
+func (repo *userRepository) Delete(ctx context.Context, data models.User, deletions []models.FileDeletion) ([]models.FileDeletion, error) {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&data).Error
+		if err != nil {
+			return err
+		}
+
+		deletions, err = scheduleDeletions(tx, deletions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+func (repo *userRepository) Follow(ctx context.Context, followerID, followeeID string) error {
+	err := repo.db.WithContext(ctx).Create(&models.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}).Error
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Unfollow returns gorm.ErrRecordNotFound when the follower doesn't follow the followee.
+func (repo *userRepository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	res := repo.db.WithContext(ctx).Delete(&models.Follow{}, "follower_id = ? AND followee_id = ?", followerID, followeeID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/handlers"
+	"photo-app/helpers"
+	"photo-app/middlewares"
+	"photo-app/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewAlbumRoutes(r *gin.RouterGroup, db *gorm.DB, conf helpers.Photo, logger *slog.Logger) {
+	repo := repositories.NewAlbumRepository(db)
+	photoRepo := repositories.NewPhotoRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	shareRepo := repositories.NewShareRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	controller := controllers.NewAlbumController(repo, photoRepo, userRepo, shareRepo, helpers.NewURLSigner(conf), logger)
+	handler := handlers.NewAlbumHandler(controller)
+
+	{
+		r.GET("/by/:username", handler.GetByOwner)
+		r.GET("/:id", middlewares.AuthMiddleware(sessions, false), middlewares.ShareMiddleware(), handler.GetByID)
+		r.Use(middlewares.AuthMiddleware(sessions, true))
+		r.GET("/my", handler.GetMine)
+		r.POST("", handler.Create)
+		r.PUT("/:id", handler.Update)
+		r.DELETE("/:id", handler.Delete)
+		r.POST("/:id/photos", handler.AddPhoto)
+		r.PUT("/:id/photos", handler.ReorderPhotos)
+		r.DELETE("/:id/photos/:photo_id", handler.RemovePhoto)
+	}
+}
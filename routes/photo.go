@@ -1,37 +1,45 @@
 package routes
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
 	"photo-app/controllers"
 	"photo-app/handlers"
+	"photo-app/helpers"
 	"photo-app/middlewares"
 	"photo-app/repositories"
+	"photo-app/storage"
 	"regexp"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func NewPhotoRoutes(api *gin.RouterGroup, static *gin.RouterGroup, db *gorm.DB, logger *slog.Logger) {
+func NewPhotoRoutes(api, files, static *gin.RouterGroup, db *gorm.DB, store storage.Storage, conf helpers.Photo, logger *slog.Logger) {
 	repo := repositories.NewPhotoRepository(db)
 	userRepo := repositories.NewUserRepository(db)
-	controller := controllers.NewPhotoController(repo, userRepo, logger)
-	handler := handlers.NewPhotoHandler(controller)
+	fileRepo := repositories.NewFileRepository(db)
+	shareRepo := repositories.NewShareRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	controller := controllers.NewPhotoController(repo, userRepo, fileRepo, shareRepo, store, conf, logger)
+	handler := handlers.NewPhotoHandler(controller, conf)
+	signer := helpers.NewURLSigner(conf)
 
 	{
-		re := regexp.MustCompile(`^/photos/[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}/([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})`)
-		static.Use(func(ctx *gin.Context) {
-			if !re.MatchString(ctx.Request.URL.String()) {
-				ctx.AbortWithStatus(http.StatusForbidden)
+		files.Use(middlewares.SignedURLMiddleware(signer), middlewares.AuthMiddleware(sessions, false), middlewares.ShareMiddleware(), func(ctx *gin.Context) {
+			// the signature of a signed URL is enough, the photo isn't looked up.
+			if ctx.GetBool("signed_url") {
+				ctx.Next()
+				return
+			}
+
+			isAllowed, err := controller.IsAllowedToView(ctx, ctx.Param("photo_id"))
+			var errController helpers.ResponseError
+			if errors.As(err, &errController) {
+				ctx.AbortWithStatus(errController.Code())
 				return
 			}
-			ctx.Next()
-		}, middlewares.AuthMiddleware(false), func(ctx *gin.Context) {
-			photoID := re.FindStringSubmatch(ctx.Request.URL.String())[1]
-			isAllowed, err := controller.IsAllowedToView(ctx, photoID)
 			if !isAllowed || err != nil {
 				ctx.AbortWithStatus(404)
 				return
@@ -39,16 +47,46 @@ func NewPhotoRoutes(api *gin.RouterGroup, static *gin.RouterGroup, db *gorm.DB,
 			ctx.Next()
 		})
 
-		static.Static("", filepath.Join(os.Getenv("PHOTO_DIR")))
+		for _, path := range []string{"/:photo_id", "/:photo_id/:variant", "/:photo_id/versions/:version", "/:photo_id/versions/:version/:variant"} {
+			files.GET(path, handler.ServePhotoFile)
+			files.HEAD(path, handler.ServePhotoFile)
+		}
+	}
+
+	{
+		// files used to be served at their storage path, /photos/<userID>/<photoID>..., those paths are redirected.
+		re := regexp.MustCompile(`^/photos/[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}/[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}`)
+		static.Use(func(ctx *gin.Context) {
+			if !re.MatchString(ctx.Request.URL.Path) {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+		}, middlewares.SignedURLMiddleware(signer))
+
+		static.GET("/*filepath", handler.RedirectFile)
+		static.HEAD("/*filepath", handler.RedirectFile)
 	}
 
 	{
 		api.GET("", handler.GetAll)
 		api.GET("/by/:username", handler.GetByOwner)
-		api.Use(middlewares.AuthMiddleware(true))
+		api.GET("/search", middlewares.AuthMiddleware(sessions, false), handler.Search)
+		api.GET("/:id", middlewares.AuthMiddleware(sessions, false), middlewares.ShareMiddleware(), handler.GetByID)
+		api.GET("/:id/metadata", middlewares.AuthMiddleware(sessions, false), middlewares.ShareMiddleware(), handler.GetMetadata)
+		api.Use(middlewares.AuthMiddleware(sessions, true))
 		api.GET("/my", handler.GetMine)
+		api.GET("/trash", handler.GetTrash)
+		api.DELETE("/trash", handler.EmptyTrash)
+		api.POST("/:id/restore", handler.Restore)
+		api.GET("/:id/original", handler.GetOriginal)
 		api.POST("", handler.Create)
 		api.PUT("/:id", handler.Update)
 		api.DELETE("/:id", handler.Delete)
+		api.PUT("/:id/file", handler.ReplaceFile)
+		api.GET("/:id/versions", handler.GetVersions)
+		api.POST("/:id/versions/:version/restore", handler.RestoreVersion)
+		api.DELETE("/:id/versions", handler.PurgeVersions)
+		api.DELETE("/:id/versions/:version", handler.DeleteVersion)
 	}
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/handlers"
+	"photo-app/middlewares"
+	"photo-app/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewShareRoutes(r *gin.RouterGroup, db *gorm.DB, logger *slog.Logger) {
+	repo := repositories.NewShareRepository(db)
+	photoRepo := repositories.NewPhotoRepository(db)
+	albumRepo := repositories.NewAlbumRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	controller := controllers.NewShareController(repo, photoRepo, albumRepo, logger)
+	handler := handlers.NewShareHandler(controller)
+
+	{
+		r.Use(middlewares.AuthMiddleware(sessions, true))
+		r.GET("/my", handler.GetMine)
+		r.POST("", handler.Create)
+		r.DELETE("/:id", handler.Revoke)
+		r.GET("/:id/uses", handler.GetUses)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"log/slog"
+	"photo-app/controllers"
+	"photo-app/handlers"
+	"photo-app/helpers"
+	"photo-app/middlewares"
+	"photo-app/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func NewTagRoutes(r *gin.RouterGroup, db *gorm.DB, conf helpers.Photo, logger *slog.Logger) {
+	repo := repositories.NewTagRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	controller := controllers.NewTagController(repo, helpers.NewURLSigner(conf), logger)
+	handler := handlers.NewTagHandler(controller)
+
+	{
+		r.GET("", handler.GetAll)
+		r.GET("/:tag/photos", middlewares.AuthMiddleware(sessions, false), handler.GetPhotos)
+	}
+}
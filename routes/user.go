@@ -4,23 +4,41 @@ import (
 	"log/slog"
 	"photo-app/controllers"
 	"photo-app/handlers"
+	"photo-app/helpers"
+	"photo-app/mailer"
 	"photo-app/middlewares"
 	"photo-app/repositories"
+	"photo-app/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func NewUserRoutes(r *gin.RouterGroup, db *gorm.DB, logger *slog.Logger) {
+func NewUserRoutes(r *gin.RouterGroup, db *gorm.DB, store storage.Storage, mail mailer.Mailer, conf helpers.Auth, mailConf helpers.Mail, logger *slog.Logger) {
 	userRepo := repositories.NewUserRepository(db)
-	userController := controllers.NewUserController(userRepo, logger)
+	fileRepo := repositories.NewFileRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	userController := controllers.NewUserController(userRepo, sessions, fileRepo, store, mail, conf, mailConf, logger)
 	userHandler := handlers.NewUserHandler(userController)
 
 	{
 		r.POST("/register", userHandler.Register)
 		r.POST("/login", userHandler.Login)
-		r.Use(middlewares.AuthMiddleware(true))
+		r.POST("/refresh", userHandler.Refresh)
+		r.POST("/logout", userHandler.Logout)
+		r.GET("/verify", userHandler.VerifyEmail)
+		r.POST("/verify", userHandler.VerifyEmail)
+		r.POST("/password/forgot", userHandler.ForgotPassword)
+		r.POST("/password/reset", userHandler.ResetPassword)
+		r.Use(middlewares.AuthMiddleware(sessions, true))
+		r.POST("/verify/resend", userHandler.ResendVerification)
+		r.GET("/me", userHandler.GetMe)
 		r.PUT("/me", userHandler.Update)
+		r.PUT("/me/settings", userHandler.UpdateSettings)
 		r.DELETE("/me", userHandler.Delete)
+		r.GET("/me/sessions", userHandler.GetSessions)
+		r.DELETE("/me/sessions/:id", userHandler.DeleteSession)
+		r.POST("/:username/follow", userHandler.Follow)
+		r.DELETE("/:username/follow", userHandler.Unfollow)
 	}
 }
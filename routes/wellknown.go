@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"photo-app/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewWellKnownRoutes(r *gin.RouterGroup) {
+	r.GET("/jwks.json", handlers.JWKS)
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type localStorage struct {
+	root string
+}
+
+func NewLocalStorage(dir string) (Storage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(root, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localStorage{root}, nil
+}
+
+func (s *localStorage) fullPath(key string) (string, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, fullPath, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(fullPath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// write into a temporary file first, so a failed upload never leaves a half written object behind.
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fullPath)
+}
+
+func (s *localStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	var obj Object
+
+	key, fullPath, err := s.fullPath(key)
+	if err != nil {
+		return nil, obj, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, obj, ErrNotFound
+		}
+		return nil, obj, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, obj, err
+	}
+
+	if info.IsDir() {
+		f.Close()
+		return nil, obj, ErrNotFound
+	}
+
+	return f, s.object(key, info), nil
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	_, fullPath, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *localStorage) Stat(ctx context.Context, key string) (Object, error) {
+	var obj Object
+
+	key, fullPath, err := s.fullPath(key)
+	if err != nil {
+		return obj, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return obj, ErrNotFound
+		}
+		return obj, err
+	}
+
+	if info.IsDir() {
+		return obj, ErrNotFound
+	}
+
+	return s.object(key, info), nil
+}
+
+func (s *localStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	return list(ctx, s, prefix)
+}
+
+// Walk only walks the directory of the prefix, which may end in the middle of a file name
+// (ex: "a/b" matches "a/b.jpg" and "a/b/c.jpg"), and skips the directories that can't match it.
+func (s *localStorage) Walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	dir := ""
+	if strings.TrimPrefix(prefix, "/") != "" {
+		key, err := cleanKey(prefix)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(prefix, "/") {
+			dir, prefix = key, key+"/"
+		} else {
+			dir, prefix = path.Dir(key), key
+		}
+	}
+	prefix = strings.TrimPrefix(prefix, "/")
+	start := filepath.Join(s.root, filepath.FromSlash(dir))
+
+	return filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// nothing was ever stored under the prefix.
+			if p == start && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if p != start && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".upload-") || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		return fn(s.object(key, info))
+	})
+}
+
+func (s *localStorage) object(key string, info fs.FileInfo) Object {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: contentType,
+		ModTime:     info.ModTime(),
+	}
+}
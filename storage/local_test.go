@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageWalk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"/u1/p1.jpg", "/u1/p1/small.webp", "/u1/p10.jpg", "/u1/p2.png", "/u2/p3.jpg", "/originals/u1/p1.jpg"} {
+		if err := store.Put(ctx, key, strings.NewReader(key), int64(len(key)), ""); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	// a temporary file left by an interrupted upload.
+	if err := os.WriteFile(filepath.Join(dir, "u1", ".upload-123"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"", []string{"originals/u1/p1.jpg", "u1/p1.jpg", "u1/p1/small.webp", "u1/p10.jpg", "u1/p2.png", "u2/p3.jpg"}},
+		{"/", []string{"originals/u1/p1.jpg", "u1/p1.jpg", "u1/p1/small.webp", "u1/p10.jpg", "u1/p2.png", "u2/p3.jpg"}},
+		{"/u1/", []string{"u1/p1.jpg", "u1/p1/small.webp", "u1/p10.jpg", "u1/p2.png"}},
+		{"/u1", []string{"u1/p1.jpg", "u1/p1/small.webp", "u1/p10.jpg", "u1/p2.png"}},
+		{"/u1/p1", []string{"u1/p1.jpg", "u1/p1/small.webp", "u1/p10.jpg"}},
+		{"/u1/p1/", []string{"u1/p1/small.webp"}},
+		{"/u1/p1.jpg", []string{"u1/p1.jpg"}},
+		{"/u1/../u2/", []string{"u2/p3.jpg"}},
+		{"/u3/", nil},
+		{"/u3/p1/", nil},
+		{"/u1/p2.png/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			var keys []string
+			err := store.Walk(ctx, tt.prefix, func(obj Object) error {
+				keys = append(keys, obj.Key)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("got %v, want %v", keys, tt.keys)
+			}
+		})
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		errStop := errors.New("stop")
+		var walked int
+		err := store.Walk(ctx, "", func(obj Object) error {
+			walked++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || walked != 1 {
+			t.Errorf("got %v after %d objects", err, walked)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		objects, err := store.List(ctx, "/u2/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objects) != 1 || objects[0].Key != "u2/p3.jpg" || objects[0].ContentType != "image/jpeg" {
+			t.Errorf("got %+v", objects)
+		}
+	})
+}
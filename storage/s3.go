@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"photo-app/helpers"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const bucketCheckTimeout = 10 * time.Second
+
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Storage creates a storage backed by any S3-compatible object storage (AWS S3, MinIO, etc).
+// the endpoint may contain a scheme (ex: http://localhost:9000), https is used otherwise. the bucket must exist.
+func NewS3Storage(conf helpers.Storage) (Storage, error) {
+	endpoint, secure := conf.S3Endpoint, true
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		endpoint, secure = u.Host, u.Scheme == "https"
+	}
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+	})
+	if conf.S3AccessKey != "" {
+		creds = credentials.NewStaticV4(conf.S3AccessKey, conf.S3SecretKey, "")
+	}
+
+	lookup := minio.BucketLookupAuto
+	if conf.S3PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       secure,
+		Region:       conf.S3Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a missing bucket would otherwise only show up as missing files.
+	ctx, cancel := context.WithTimeout(context.Background(), bucketCheckTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, conf.S3Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %q doesn't exist", conf.S3Bucket)
+	}
+
+	return &s3Storage{
+		client: client,
+		bucket: conf.S3Bucket,
+		prefix: strings.Trim(conf.S3Prefix, "/"),
+	}, nil
+}
+
+func (s *s3Storage) objectName(key string) (string, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, path.Join(s.prefix, key), nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return s.err(err)
+	}
+
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	var obj Object
+
+	key, name, err := s.objectName(key)
+	if err != nil {
+		return nil, obj, err
+	}
+
+	o, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, obj, s.err(err)
+	}
+
+	// GetObject is lazy, the request is only sent once the object is read or stat-ed.
+	info, err := o.Stat()
+	if err != nil {
+		o.Close()
+		return nil, obj, s.err(err)
+	}
+
+	return o, s.object(key, info), nil
+}
+
+// Delete removes an object. S3 doesn't report missing objects on delete, so unlike the local storage
+// this never returns ErrNotFound.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+	if err != nil {
+		return s.err(err)
+	}
+
+	return nil
+}
+
+func (s *s3Storage) Stat(ctx context.Context, key string) (Object, error) {
+	var obj Object
+
+	key, name, err := s.objectName(key)
+	if err != nil {
+		return obj, err
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return obj, s.err(err)
+	}
+
+	return s.object(key, info), nil
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
+	return list(ctx, s, prefix)
+}
+
+func (s *s3Storage) Walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if s.prefix != "" {
+		prefix = s.prefix + "/" + prefix
+	}
+
+	// the listing goroutine of the client only stops once its context is done.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if info.Err != nil {
+			return s.err(info.Err)
+		}
+
+		key := strings.TrimPrefix(info.Key, s.prefix+"/")
+		if err := fn(s.object(key, info)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *s3Storage) object(key string, info minio.ObjectInfo) Object {
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return Object{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: contentType,
+		ModTime:     info.LastModified,
+	}
+}
+
+// err maps a missing object to ErrNotFound. other errors, such as a missing bucket, are returned as is so a
+// misconfiguration isn't mistaken for a missing file. HEAD responses have no body to tell a missing bucket apart,
+// which is why the bucket is checked when the storage is created.
+func (s *s3Storage) err(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"photo-app/helpers"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-process S3 server implementing the few calls the storage makes, with path-style bucket lookup.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+type fakeListResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Name        string
+	Prefix      string
+	KeyCount    int
+	MaxKeys     int
+	IsTruncated bool
+	Contents    []fakeListObject
+}
+
+type fakeListObject struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			f.error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = fakeObject{data, r.Header.Get("Content-Type"), time.Now().UTC().Truncate(time.Second)}
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			f.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	default:
+		f.error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	res := fakeListResult{Name: f.bucket, Prefix: prefix, MaxKeys: 1000}
+	for key, obj := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			res.Contents = append(res.Contents, fakeListObject{
+				Key:          key,
+				LastModified: obj.modTime.Format("2006-01-02T15:04:05.000Z"),
+				ETag:         `"etag"`,
+				Size:         len(obj.data),
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+	slices.SortFunc(res.Contents, func(a, b fakeListObject) int { return strings.Compare(a.Key, b.Key) })
+	res.KeyCount = len(res.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(res)
+}
+
+func (f *fakeS3) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// readBody reads the body of an upload, decoding the aws-chunked encoding the client uses over plain HTTP.
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return data, nil
+		}
+
+		chunk := make([]byte, n+2)
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:n]...)
+	}
+}
+
+func newTestS3(t *testing.T, bucket string) (Storage, *fakeS3, error) {
+	fake := &fakeS3{bucket: "photos", objects: make(map[string]fakeObject)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3Storage(helpers.Storage{
+		S3Bucket:    bucket,
+		S3Prefix:    "/app/",
+		S3Endpoint:  srv.URL,
+		S3Region:    "us-east-1",
+		S3PathStyle: true,
+		S3AccessKey: "access",
+		S3SecretKey: "secret",
+	})
+
+	return store, fake, err
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	store, fake, err := newTestS3(t, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"/u1/p1.jpg":        "jpeg",
+		"/u1/p1/small.webp": "small",
+		"/u1/p2.png":        "png",
+		"/u2/p3.jpg":        "other user",
+	}
+	for key, content := range files {
+		if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), mime.TypeByExtension(path.Ext(key))); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	if _, ok := fake.objects["app/u1/p1.jpg"]; !ok {
+		t.Fatal("objects aren't stored under the prefix")
+	}
+
+	t.Run("get", func(t *testing.T) {
+		for key, content := range files {
+			r, obj, err := store.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("get %s: %v", key, err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || string(data) != content {
+				t.Errorf("get %s: got %q, %v", key, data, err)
+			}
+			if obj.Key != strings.TrimPrefix(key, "/") || obj.Size != int64(len(content)) {
+				t.Errorf("get %s: got %+v", key, obj)
+			}
+		}
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		obj, err := store.Stat(ctx, "/u1/p2.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if obj.Key != "u1/p2.png" || obj.Size != 3 || obj.ContentType != "image/png" || obj.ModTime.IsZero() {
+			t.Errorf("got %+v", obj)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := store.Stat(ctx, "/u1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("stat: got %v, want ErrNotFound", err)
+		}
+		if _, _, err := store.Get(ctx, "/u1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if err := store.Put(ctx, "/../", strings.NewReader(""), 0, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("got %v, want ErrInvalidKey", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		objects, err := store.List(ctx, "/u1/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := objectKeys(objects); !slices.Equal(got, []string{"u1/p1.jpg", "u1/p1/small.webp", "u1/p2.png"}) {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("walk stop", func(t *testing.T) {
+		errStop := errors.New("stop")
+		var walked int
+		err := store.Walk(ctx, "/u1/", func(obj Object) error {
+			walked++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || walked != 1 {
+			t.Errorf("got %v after %d objects", err, walked)
+		}
+	})
+
+	// a prefix deletion lists the files under the prefix and deletes each of them, as the file controller does.
+	t.Run("delete prefix", func(t *testing.T) {
+		objects, err := store.List(ctx, "/u1/p1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, obj := range objects {
+			if err := store.Delete(ctx, obj.Key); err != nil {
+				t.Fatalf("delete %s: %v", obj.Key, err)
+			}
+		}
+
+		objects, err = store.List(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := objectKeys(objects); !slices.Equal(got, []string{"u1/p2.png", "u2/p3.jpg"}) {
+			t.Errorf("got %v", got)
+		}
+		if _, err := store.Stat(ctx, "/u1/p1.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted object: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestS3StorageMissingBucket(t *testing.T) {
+	if _, _, err := newTestS3(t, "misconfigured"); err == nil {
+		t.Error("a storage was created for a missing bucket")
+	}
+
+	// the bucket disappears once the storage is running.
+	ctx := context.Background()
+	store, fake, err := newTestS3(t, "photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	fake.bucket = "renamed"
+	fake.mu.Unlock()
+
+	if _, err := store.List(ctx, ""); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("list: got %v, want a bucket error", err)
+	}
+	if err := store.Put(ctx, "/u1/p1.jpg", bytes.NewReader(nil), 0, ""); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("put: got %v, want a bucket error", err)
+	}
+}
+
+func objectKeys(objects []Object) []string {
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"photo-app/helpers"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (Object, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Walk calls fn for every object whose key starts with prefix, without loading them all in memory.
+	// it stops at the first error returned by fn.
+	Walk(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+func New(conf helpers.Storage) (Storage, error) {
+	switch conf.Backend {
+	case "", "local":
+		return NewLocalStorage(conf.PhotoDir)
+	case "s3":
+		return NewS3Storage(conf)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", conf.Backend)
+	}
+}
+
+// list collects the objects walked under prefix.
+func list(ctx context.Context, s Storage, prefix string) ([]Object, error) {
+	var objects []Object
+
+	err := s.Walk(ctx, prefix, func(obj Object) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// cleanKey normalizes a key into a slash separated path relative to the storage root,
+// so a key can never point outside of it (ex: "/../a/b.jpg" becomes "a/b.jpg").
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+
+	return key, nil
+}